@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "remaining";
+DROP TABLE IF EXISTS "coming_table_product";
+DROP TABLE IF EXISTS "coming_table";
+DROP TABLE IF EXISTS "product";
+DROP TABLE IF EXISTS "category";
+DROP TABLE IF EXISTS "branches";
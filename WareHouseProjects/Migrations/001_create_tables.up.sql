@@ -1,4 +1,4 @@
-CREATE TABLE "branches" (
+CREATE TABLE IF NOT EXISTS "branches" (
   "id" uuid PRIMARY KEY,
   "name" varchar NOT NULL,
   "address" varchar,
@@ -7,15 +7,15 @@ CREATE TABLE "branches" (
   "updated_at" timestamp
 );
 
-CREATE TABLE "category" (
-  "id" UUID PRIMARY KEY,
-  "name" VARCHAR NOT NULL,
-  "parent_id" UUID REFERENCES "category"("id"),
-  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  "updated_at" TIMESTAMP
+CREATE TABLE IF NOT EXISTS "category" (
+  "id" uuid PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "parent_id" uuid REFERENCES "category"("id"),
+  "created_at" timestamp DEFAULT current_timestamp,
+  "updated_at" timestamp
 );
 
-CREATE TABLE "product" (
+CREATE TABLE IF NOT EXISTS "product" (
   "id" uuid PRIMARY KEY,
   "name" varchar NOT NULL,
   "price" numeric NOT NULL,
@@ -25,7 +25,7 @@ CREATE TABLE "product" (
   "updated_at" timestamp
 );
 
-CREATE TABLE "coming_table" (
+CREATE TABLE IF NOT EXISTS "coming_table" (
   "id" uuid PRIMARY KEY,
   "coming_id" varchar NOT NULL,
   "branch_id" uuid REFERENCES "branches"("id"),
@@ -35,42 +35,30 @@ CREATE TABLE "coming_table" (
   "updated_at" timestamp
 );
 
-CREATE TABLE "coming_table_product" (
+CREATE TABLE IF NOT EXISTS "coming_table_product" (
   "id" uuid PRIMARY KEY,
   "category_id" uuid REFERENCES "category"("id"),
   "name" varchar NOT NULL,
   "price" numeric NOT NULL,
-  "barcode" varchar UNIQUE NOT NULL,
+  "barcode" varchar NOT NULL,
   "count" numeric NOT NULL DEFAULT 0,
   "total_price" numeric DEFAULT 0,
-  "coming_table_id" uuid REFERENCES "coming_table"("id"),
+  "coming_table_id" uuid REFERENCES "coming_table"("id") ON DELETE CASCADE,
   "created_at" timestamp DEFAULT current_timestamp,
-  "updated_at" timestamp
+  "updated_at" timestamp,
+  UNIQUE ("coming_table_id", "barcode")
 );
 
-CREATE TABLE "remaining" (
+CREATE TABLE IF NOT EXISTS "remaining" (
   "id" uuid PRIMARY KEY,
   "branch_id" uuid REFERENCES "branches"("id"),
   "category_id" uuid REFERENCES "category"("id"),
   "name" varchar NOT NULL,
   "price" numeric NOT NULL,
-  "barcode" varchar UNIQUE NOT NULL,
+  "barcode" varchar NOT NULL,
   "count" numeric NOT NULL DEFAULT 0,
   "total_price" numeric DEFAULT 0,
-  "created_at" timestamp DEFAULT (current_timestamp),
-  "updated_at" timestamp
+  "created_at" timestamp DEFAULT current_timestamp,
+  "updated_at" timestamp,
+  UNIQUE ("branch_id", "barcode")
 );
-
-ALTER TABLE "category" ADD FOREIGN KEY ("parent_id") REFERENCES "category" ("id");
-
-ALTER TABLE "product" ADD FOREIGN KEY ("category_id") REFERENCES "category" ("id");
-
-ALTER TABLE "coming_table" ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
-
-ALTER TABLE "coming_table_product" ADD FOREIGN KEY ("category_id") REFERENCES "category" ("id");
-
-ALTER TABLE "coming_table_product" ADD FOREIGN KEY ("coming_table_id") REFERENCES "coming_table" ("id");
-
-ALTER TABLE "remaining" ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
-
-ALTER TABLE "remaining" ADD FOREIGN KEY ("category_id") REFERENCES "category" ("id");
\ No newline at end of file
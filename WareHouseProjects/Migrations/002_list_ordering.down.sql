@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS "remaining_created_at_id_idx";
+DROP INDEX IF EXISTS "coming_table_product_created_at_id_idx";
+DROP INDEX IF EXISTS "coming_table_date_time_id_idx";
+DROP INDEX IF EXISTS "coming_table_created_at_id_idx";
+DROP INDEX IF EXISTS "product_created_at_id_idx";
+DROP INDEX IF EXISTS "category_created_at_id_idx";
+DROP INDEX IF EXISTS "branches_created_at_id_idx";
+
+ALTER TABLE "remaining" ALTER COLUMN "total_price" DROP NOT NULL;
+ALTER TABLE "remaining" ALTER COLUMN "created_at" DROP NOT NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "total_price" DROP NOT NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "created_at" DROP NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "status" DROP NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "date_time" DROP NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "created_at" DROP NOT NULL;
+ALTER TABLE "product" ALTER COLUMN "created_at" DROP NOT NULL;
+ALTER TABLE "category" ALTER COLUMN "created_at" DROP NOT NULL;
+ALTER TABLE "branches" ALTER COLUMN "created_at" DROP NOT NULL;
@@ -0,0 +1,31 @@
+-- List endpoints page by (sort column, id). Keyset comparisons skip NULLs, so
+-- the sortable columns must never be NULL.
+UPDATE "branches" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "category" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "product" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "coming_table" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "coming_table" SET "date_time" = "created_at" WHERE "date_time" IS NULL;
+UPDATE "coming_table" SET "status" = 'in_process' WHERE "status" IS NULL;
+UPDATE "coming_table_product" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "coming_table_product" SET "total_price" = "price" * "count" WHERE "total_price" IS NULL;
+UPDATE "remaining" SET "created_at" = current_timestamp WHERE "created_at" IS NULL;
+UPDATE "remaining" SET "total_price" = "price" * "count" WHERE "total_price" IS NULL;
+
+ALTER TABLE "branches" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "category" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "product" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "date_time" SET NOT NULL;
+ALTER TABLE "coming_table" ALTER COLUMN "status" SET NOT NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "total_price" SET NOT NULL;
+ALTER TABLE "remaining" ALTER COLUMN "created_at" SET NOT NULL;
+ALTER TABLE "remaining" ALTER COLUMN "total_price" SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "branches_created_at_id_idx" ON "branches" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "category_created_at_id_idx" ON "category" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "product_created_at_id_idx" ON "product" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "coming_table_created_at_id_idx" ON "coming_table" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "coming_table_date_time_id_idx" ON "coming_table" ("date_time", "id");
+CREATE INDEX IF NOT EXISTS "coming_table_product_created_at_id_idx" ON "coming_table_product" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "remaining_created_at_id_idx" ON "remaining" ("created_at", "id");
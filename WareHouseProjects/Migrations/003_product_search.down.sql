@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "category_name_trgm_idx";
+DROP INDEX IF EXISTS "product_barcode_trgm_idx";
+DROP INDEX IF EXISTS "product_name_trgm_idx";
+DROP INDEX IF EXISTS "product_search_idx";
@@ -0,0 +1,9 @@
+-- Product search ranks full-text matches over name, barcode and category name
+-- and falls back to trigram similarity for misspelled names.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "product_search_idx" ON "product"
+  USING gin (to_tsvector('simple', "name" || ' ' || "barcode"));
+CREATE INDEX IF NOT EXISTS "product_name_trgm_idx" ON "product" USING gin ("name" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "product_barcode_trgm_idx" ON "product" USING gin ("barcode" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "category_name_trgm_idx" ON "category" USING gin ("name" gin_trgm_ops);
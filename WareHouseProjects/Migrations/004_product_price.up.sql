@@ -0,0 +1,21 @@
+-- Every price a product had or is scheduled to have. A change is scheduled
+-- while "applied_at" is NULL and is applied once "effective_at" has passed;
+-- "old_price" is the price it replaced.
+CREATE TABLE IF NOT EXISTS "product_price" (
+  "id" uuid PRIMARY KEY,
+  "product_id" uuid NOT NULL REFERENCES "product"("id") ON DELETE CASCADE,
+  "old_price" numeric,
+  "price" numeric NOT NULL,
+  "effective_at" timestamp NOT NULL,
+  "applied_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "product_price_product_id_idx" ON "product_price" ("product_id", "effective_at");
+CREATE INDEX IF NOT EXISTS "product_price_due_idx" ON "product_price" ("effective_at") WHERE "applied_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "product_price_created_at_id_idx" ON "product_price" ("created_at", "id");
+
+-- The current prices start the history.
+INSERT INTO "product_price" ("id", "product_id", "price", "effective_at", "applied_at", "created_at")
+SELECT gen_random_uuid(), "id", "price", "created_at", "created_at", "created_at"
+FROM "product";
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "coming_table_product_barcode_idx";
+ALTER TABLE "remaining" DROP COLUMN IF EXISTS "cost";
+ALTER TABLE "coming_table_product" DROP COLUMN IF EXISTS "cost";
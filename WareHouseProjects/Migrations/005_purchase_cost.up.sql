@@ -0,0 +1,15 @@
+-- Arrival lines and stock carry the purchase cost apart from the selling
+-- price. "total_price" of both is valued at cost; "remaining"."cost" is the
+-- average cost of the units in stock. Costs so far were the selling price.
+ALTER TABLE "coming_table_product" ADD COLUMN IF NOT EXISTS "cost" numeric;
+UPDATE "coming_table_product" SET "cost" = "price" WHERE "cost" IS NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "cost" SET NOT NULL;
+
+ALTER TABLE "remaining" ADD COLUMN IF NOT EXISTS "cost" numeric;
+UPDATE "remaining"
+SET "cost" = CASE WHEN "count" > 0 THEN "total_price" / "count" ELSE "price" END
+WHERE "cost" IS NULL;
+ALTER TABLE "remaining" ALTER COLUMN "cost" SET NOT NULL;
+
+-- The last purchase cost of a product is looked up by barcode.
+CREATE INDEX IF NOT EXISTS "coming_table_product_barcode_idx" ON "coming_table_product" ("barcode");
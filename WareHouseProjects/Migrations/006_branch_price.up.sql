@@ -0,0 +1,13 @@
+-- A branch may sell a product at its own price instead of "product"."price".
+CREATE TABLE IF NOT EXISTS "branch_price" (
+  "id" uuid PRIMARY KEY,
+  "branch_id" uuid NOT NULL REFERENCES "branches"("id") ON DELETE CASCADE,
+  "product_id" uuid NOT NULL REFERENCES "product"("id") ON DELETE CASCADE,
+  "price" numeric NOT NULL CHECK ("price" > 0),
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamp,
+  UNIQUE ("branch_id", "product_id")
+);
+
+CREATE INDEX IF NOT EXISTS "branch_price_product_id_idx" ON "branch_price" ("product_id");
+CREATE INDEX IF NOT EXISTS "branch_price_created_at_id_idx" ON "branch_price" ("created_at", "id");
@@ -0,0 +1,22 @@
+-- Units a product is counted in. "precision" is the number of decimals a
+-- count may have: 0 allows whole units only.
+CREATE TABLE IF NOT EXISTS "unit" (
+  "id" uuid PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "short_name" varchar NOT NULL UNIQUE,
+  "precision" smallint NOT NULL CHECK ("precision" BETWEEN 0 AND 6),
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "unit_created_at_id_idx" ON "unit" ("created_at", "id");
+
+INSERT INTO "unit" ("id", "name", "short_name", "precision") VALUES
+  (gen_random_uuid(), 'piece', 'pcs', 0),
+  (gen_random_uuid(), 'kilogram', 'kg', 3),
+  (gen_random_uuid(), 'liter', 'l', 3)
+ON CONFLICT ("short_name") DO NOTHING;
+
+-- A product without a unit may be counted with any precision.
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS "unit_id" uuid REFERENCES "unit"("id");
+CREATE INDEX IF NOT EXISTS "product_unit_id_idx" ON "product" ("unit_id");
@@ -0,0 +1,5 @@
+ALTER TABLE "coming_table_product" DROP COLUMN IF EXISTS "doc_total_price";
+ALTER TABLE "coming_table_product" DROP COLUMN IF EXISTS "doc_cost";
+ALTER TABLE "coming_table" DROP COLUMN IF EXISTS "exchange_rate";
+ALTER TABLE "coming_table" DROP COLUMN IF EXISTS "currency";
+DROP TABLE IF EXISTS "exchange_rate";
@@ -0,0 +1,26 @@
+-- Exchange rates to the base currency: 1 "currency" is worth "rate" units of
+-- the base currency from "date" until the next rate of that currency.
+CREATE TABLE IF NOT EXISTS "exchange_rate" (
+  "id" uuid PRIMARY KEY,
+  "currency" varchar(3) NOT NULL,
+  "rate" numeric NOT NULL CHECK ("rate" > 0),
+  "date" date NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamp,
+  UNIQUE ("currency", "date")
+);
+
+CREATE INDEX IF NOT EXISTS "exchange_rate_created_at_id_idx" ON "exchange_rate" ("created_at", "id");
+
+-- A coming table is invoiced in "currency", NULL for the base currency, at
+-- "exchange_rate" base units per unit.
+ALTER TABLE "coming_table" ADD COLUMN IF NOT EXISTS "currency" varchar(3);
+ALTER TABLE "coming_table" ADD COLUMN IF NOT EXISTS "exchange_rate" numeric NOT NULL DEFAULT 1 CHECK ("exchange_rate" > 0);
+
+-- "cost" and "total_price" of a line stay in the base currency; the "doc_"
+-- columns hold them in the currency of the coming table.
+ALTER TABLE "coming_table_product" ADD COLUMN IF NOT EXISTS "doc_cost" numeric;
+ALTER TABLE "coming_table_product" ADD COLUMN IF NOT EXISTS "doc_total_price" numeric;
+UPDATE "coming_table_product" SET "doc_cost" = "cost", "doc_total_price" = COALESCE("total_price", 0) WHERE "doc_cost" IS NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "doc_cost" SET NOT NULL;
+ALTER TABLE "coming_table_product" ALTER COLUMN "doc_total_price" SET NOT NULL;
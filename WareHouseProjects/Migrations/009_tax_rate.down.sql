@@ -0,0 +1,4 @@
+ALTER TABLE "coming_table_product" DROP COLUMN IF EXISTS "tax_rate";
+ALTER TABLE "category" DROP COLUMN IF EXISTS "tax_rate_id";
+ALTER TABLE "product" DROP COLUMN IF EXISTS "tax_rate_id";
+DROP TABLE IF EXISTS "tax_rate";
@@ -0,0 +1,18 @@
+-- Tax rates in percent. A product is taxed at its own rate, else at the rate
+-- of its category or the nearest parent category that has one, else at 0.
+CREATE TABLE IF NOT EXISTS "tax_rate" (
+  "id" uuid PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "rate" numeric NOT NULL CHECK ("rate" >= 0 AND "rate" <= 100),
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "tax_rate_created_at_id_idx" ON "tax_rate" ("created_at", "id");
+
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS "tax_rate_id" uuid REFERENCES "tax_rate"("id");
+ALTER TABLE "category" ADD COLUMN IF NOT EXISTS "tax_rate_id" uuid REFERENCES "tax_rate"("id");
+
+-- The rate a line is taxed at, taken from its product when it was scanned.
+-- "total_price" is the net amount of the line.
+ALTER TABLE "coming_table_product" ADD COLUMN IF NOT EXISTS "tax_rate" numeric NOT NULL DEFAULT 0 CHECK ("tax_rate" >= 0);
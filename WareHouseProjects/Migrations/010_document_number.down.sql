@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "document_sequence";
+DROP INDEX IF EXISTS "branches_code_key";
+ALTER TABLE "branches" DROP COLUMN IF EXISTS "code";
@@ -0,0 +1,22 @@
+-- A short unique code of each branch, the prefix of its document numbers.
+-- Existing branches are numbered B001, B002, ... in the order they were
+-- created and can be renamed afterwards.
+ALTER TABLE "branches" ADD COLUMN IF NOT EXISTS "code" varchar(16);
+UPDATE "branches" b
+SET "code" = 'B' || lpad(s."n"::text, 3, '0')
+FROM (SELECT "id", row_number() OVER (ORDER BY "created_at", "id") AS "n" FROM "branches") s
+WHERE s."id" = b."id" AND b."code" IS NULL;
+ALTER TABLE "branches" ALTER COLUMN "code" SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "branches_code_key" ON "branches" ("code");
+
+-- The last number issued per branch, document type and year. The row is
+-- locked by the transaction that takes the next number until it commits, so
+-- concurrent documents wait for each other and a rolled back one leaves no
+-- gap.
+CREATE TABLE IF NOT EXISTS "document_sequence" (
+  "branch_id" uuid NOT NULL REFERENCES "branches"("id") ON DELETE CASCADE,
+  "document_type" varchar NOT NULL,
+  "year" integer NOT NULL,
+  "last_number" bigint NOT NULL CHECK ("last_number" > 0),
+  PRIMARY KEY ("branch_id", "document_type", "year")
+);
@@ -0,0 +1,36 @@
+-- Users sign in with a username and a bcrypt-hashed password.
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL UNIQUE,
+  "password_hash" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp,
+  "updated_at" timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "users_created_at_id_idx" ON "users" ("created_at", "id");
+
+-- Issued refresh tokens. Each can be used once, to get the next pair.
+CREATE TABLE IF NOT EXISTS "refresh_token" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "refresh_token_user_id_idx" ON "refresh_token" ("user_id");
+
+-- API keys of devices such as scanners, acting as "user_id". Only the
+-- SHA-256 of a key is kept; "prefix" is its start, to tell keys apart.
+CREATE TABLE IF NOT EXISTS "api_key" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  "prefix" varchar NOT NULL,
+  "key_hash" varchar NOT NULL UNIQUE,
+  "last_used_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "api_key_created_at_id_idx" ON "api_key" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "api_key_user_id_idx" ON "api_key" ("user_id");
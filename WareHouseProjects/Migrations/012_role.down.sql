@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_clerk_branch_check";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "branch_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
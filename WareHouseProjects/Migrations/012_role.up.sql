@@ -0,0 +1,10 @@
+-- The role of each user decides what it may do, see config.Policy. Users
+-- created before roles existed had full access and become admins. A user
+-- with a branch only sees the coming tables and stock of that branch; a
+-- clerk always has one.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "role" varchar NOT NULL DEFAULT 'admin'
+  CHECK ("role" IN ('admin', 'manager', 'clerk', 'auditor'));
+ALTER TABLE "users" ALTER COLUMN "role" DROP DEFAULT;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "branch_id" uuid REFERENCES "branches"("id");
+ALTER TABLE "users" ADD CONSTRAINT "users_clerk_branch_check"
+  CHECK ("role" <> 'clerk' OR "branch_id" IS NOT NULL);
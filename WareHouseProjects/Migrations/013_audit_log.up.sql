@@ -0,0 +1,18 @@
+-- Every create, update and delete of an entity, with the row before and
+-- after it as JSON. "actor_id" is the user who made the change, null for
+-- changes the server makes on its own such as applying scheduled prices. It
+-- is not a foreign key so that the log outlives deleted users.
+CREATE TABLE IF NOT EXISTS "audit_log" (
+  "id" uuid PRIMARY KEY,
+  "actor_id" uuid,
+  "entity_type" varchar NOT NULL,
+  "entity_id" uuid NOT NULL,
+  "action" varchar NOT NULL CHECK ("action" IN ('create', 'update', 'delete')),
+  "before" jsonb,
+  "after" jsonb,
+  "created_at" timestamp NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS "audit_log_created_at_id_idx" ON "audit_log" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "audit_log_entity_idx" ON "audit_log" ("entity_type", "entity_id");
+CREATE INDEX IF NOT EXISTS "audit_log_actor_id_idx" ON "audit_log" ("actor_id");
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema files so the binary can
+// migrate a database without the source tree at hand.
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
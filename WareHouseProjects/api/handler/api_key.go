@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateApiKey godoc
+// @Router       /api_key [POST]
+// @Summary      CREATE API KEY
+// @Description  issues an api key for a device such as a scanner, acting as user_id or the caller; the key is only shown in this response and is sent in X-API-Key
+// @Tags         api_key
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateApiKey  true  "api key data"
+// @Success      201  {object}  response.Response{data=models.CreatedApiKey}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateApiKey(c *gin.Context) {
+	var apiKey models.CreateApiKey
+	if !h.bind(c, &apiKey) {
+		return
+	}
+	if apiKey.User_id == "" {
+		apiKey.User_id = c.GetString(userIDKey)
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		h.handleError(c, "error api key create:", err)
+		return
+	}
+	apiKey.Prefix, apiKey.KeyHash = prefix, auth.HashAPIKey(key)
+
+	resp, err := h.storage.ApiKey().CreateApiKey(c.Request.Context(), &apiKey)
+	if err != nil {
+		h.handleError(c, "error api key create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", models.CreatedApiKey{ID: resp, Key: key})
+}
+
+// GetApiKey godoc
+// @Router       /api_key/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets api key by ID, without the key itself
+// @Tags         api_key
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Api key ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.ApiKey}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetApiKey(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.ApiKey().GetApiKey(c.Request.Context(), &models.ApiKeyIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get api key:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllApiKey godoc
+// @Router       /api_key [GET]
+// @Summary      LIST API KEY
+// @Description  gets the api keys, of one user if user_id is given
+// @Tags         api_key
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        user_id         query     string    false  "user id" format(uuid)
+// @Success      200  {object}  response.Response{data=[]models.ApiKey,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllApiKey(c *gin.Context) {
+	var req models.GetAllApiKeyRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "api_keys", &req.ListRequest, func() ([]models.ApiKey, string, error) {
+			resp, err := h.storage.ApiKey().GetAllApiKey(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.ApiKeys, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.ApiKey().GetAllApiKey(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error ApiKey GetAllApiKey:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.ApiKeys, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// DeleteApiKey godoc
+// @Router       /api_key/{id} [DELETE]
+// @Summary      DELETE API KEY BY ID
+// @Description  revokes an api key
+// @Tags         api_key
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of api key" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteApiKey(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.ApiKey().DeleteApiKey(c.Request.Context(), &models.ApiKeyIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting api key:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAllAudit godoc
+// @Router       /audit [GET]
+// @Summary      LIST AUDIT
+// @Description  gets the audit log, every create, update and delete with the user who made it and the entity before and after, by entity, actor, action and time
+// @Tags         audit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        entity_type     query     string    false  "entity type" Enums(branch, branch_price, category, exchange_rate, unit, tax_rate, product, product_price, coming_table, coming_table_product, remain, user, api_key)
+// @Param        entity_id       query     string    false  "entity" format(uuid)
+// @Param        actor_id        query     string    false  "user who made the change" format(uuid)
+// @Param        action          query     string    false  "action" Enums(create, update, delete)
+// @Param        from            query     string    false  "changed at or after, RFC 3339" format(date-time)
+// @Param        to              query     string    false  "changed before, RFC 3339" format(date-time)
+// @Success      200  {object}  response.Response{data=[]models.AuditEntry,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllAudit(c *gin.Context) {
+	var req models.GetAllAuditRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "audit", &req.ListRequest, func() ([]models.AuditEntry, string, error) {
+			resp, err := h.storage.Audit().GetAllAudit(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Entries, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.Audit().GetAllAudit(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error Audit GetAllAudit:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.Entries, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/config"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/auth"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// userIDKey and roleKey are the gin context keys of the id and the role of
+// the authenticated user.
+const (
+	userIDKey = "user_id"
+	roleKey   = "role"
+)
+
+// apiKeyHeader carries the API key of a device.
+const apiKeyHeader = "X-API-Key"
+
+// Login godoc
+// @Router       /auth/login [POST]
+// @Summary      LOGIN
+// @Description  trades a username and password for an access and a refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.LoginRequest  true  "credentials"
+// @Success      200  {object}  response.Response{data=models.TokenResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	creds, err := h.storage.User().GetUserCredentials(c.Request.Context(), req.Username)
+	hash := auth.DummyHash
+	if err == nil {
+		hash = creds.PasswordHash
+	}
+	// The password is checked for unknown usernames too, so both fail alike.
+	matches := auth.CheckPassword(hash, req.Password)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && !matches) {
+		h.log.Warn("failed login", logger.String("username", req.Username))
+		response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "invalid username or password")
+		return
+	}
+	if err != nil {
+		h.handleError(c, "error login:", err)
+		return
+	}
+
+	tokens, err := h.issueTokens(c.Request.Context(), h.storage, creds.ID)
+	if err != nil {
+		h.handleError(c, "error login:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", tokens)
+}
+
+// RefreshToken godoc
+// @Router       /auth/refresh [POST]
+// @Summary      REFRESH TOKENS
+// @Description  trades a refresh token for a new pair of tokens; each refresh token works once
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.RefreshRequest  true  "refresh token"
+// @Success      200  {object}  response.Response{data=models.TokenResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	claims, err := auth.ParseToken([]byte(h.cfg.JWTSecret), auth.RefreshToken, req.RefreshToken)
+	if err != nil {
+		h.log.Warn("invalid refresh token", logger.Error(err))
+		response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "invalid refresh token")
+		return
+	}
+
+	var tokens *models.TokenResponse
+	err = h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		userID, err := strg.User().UseRefreshToken(c.Request.Context(), claims.ID)
+		if err != nil {
+			return err
+		}
+		tokens, err = h.issueTokens(c.Request.Context(), strg, userID)
+		return err
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "invalid refresh token")
+		return
+	}
+	if err != nil {
+		h.handleError(c, "error refresh token:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", tokens)
+}
+
+// Logout godoc
+// @Router       /auth/logout [POST]
+// @Summary      LOGOUT
+// @Description  revokes a refresh token; access tokens issued with it stay valid until they expire
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.RefreshRequest  true  "refresh token"
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	// Tokens that are invalid or used already are as good as revoked.
+	claims, err := auth.ParseToken([]byte(h.cfg.JWTSecret), auth.RefreshToken, req.RefreshToken)
+	if err == nil {
+		_, err = h.storage.User().UseRefreshToken(c.Request.Context(), claims.ID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			h.handleError(c, "error logout:", err)
+			return
+		}
+	}
+
+	response.OK(c, http.StatusOK, "logged out", nil)
+}
+
+// issueTokens signs a new pair of tokens for userID and records the refresh
+// token in strg.
+func (h *Handler) issueTokens(ctx context.Context, strg storage.StorageI, userID string) (*models.TokenResponse, error) {
+	secret := []byte(h.cfg.JWTSecret)
+
+	access, err := auth.NewToken(secret, auth.AccessToken, userID, "", h.cfg.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshID := uuid.NewString()
+	if err := strg.User().CreateRefreshToken(ctx, &models.CreateRefreshToken{
+		ID:     refreshID,
+		UserID: userID,
+		TTL:    int(config.TimeExpiredAt.Seconds()),
+	}); err != nil {
+		return nil, err
+	}
+	refresh, err := auth.NewToken(secret, auth.RefreshToken, userID, refreshID, config.TimeExpiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Authenticate rejects requests that carry neither a valid access token in
+// "Authorization: Bearer" nor a known API key in X-API-Key. It notes the user
+// of the others under userIDKey and roleKey and as the actor of the changes
+// the request makes, and limits the storage calls of a user of a branch to
+// that branch.
+func (h *Handler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			userID, err := h.storage.ApiKey().GetApiKeyUser(c.Request.Context(), auth.HashAPIKey(key))
+			if errors.Is(err, storage.ErrNotFound) {
+				response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "invalid api key")
+				c.Abort()
+				return
+			}
+			if err != nil {
+				h.handleError(c, "error authenticate:", err)
+				c.Abort()
+				return
+			}
+			h.setUser(c, userID)
+			return
+		}
+
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+			c.Abort()
+			return
+		}
+		claims, err := auth.ParseToken([]byte(h.cfg.JWTSecret), auth.AccessToken, token)
+		if err != nil {
+			response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "invalid or expired access token")
+			c.Abort()
+			return
+		}
+
+		h.setUser(c, claims.Subject)
+	}
+}
+
+// setUser loads the authenticated user, so that a change of role or branch
+// applies to tokens issued before it, and serves the request as that user.
+func (h *Handler) setUser(c *gin.Context, userID string) {
+	user, err := h.storage.User().GetUser(c.Request.Context(), &models.UserIdRequest{Id: userID})
+	if errors.Is(err, storage.ErrNotFound) {
+		response.Error(c, http.StatusUnauthorized, CodeUnauthorized, "user no longer exists")
+		c.Abort()
+		return
+	}
+	if err != nil {
+		h.handleError(c, "error authenticate:", err)
+		c.Abort()
+		return
+	}
+
+	c.Set(userIDKey, user.ID)
+	c.Set(roleKey, string(user.Role))
+	ctx := storage.WithActor(c.Request.Context(), user.ID)
+	if user.Branch_id != "" {
+		ctx = storage.WithBranchScope(ctx, user.Branch_id)
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+// Authorize lets through the requests whose user's role may call their
+// method on object according to config.Config.Policy, and answers the others
+// with a 403. It panics on an object missing from config.Config.Objects, a
+// typo in the routes.
+func (h *Handler) Authorize(object string) gin.HandlerFunc {
+	if !slices.Contains(h.cfg.Objects, object) {
+		panic("authorize: unknown object " + object)
+	}
+	return func(c *gin.Context) {
+		if !h.allowed(c, object, c.Request.Method) {
+			h.forbidden(c, object, c.Request.Method)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// allowed reports whether the role of the user may call method on object.
+func (h *Handler) allowed(c *gin.Context, object, method string) bool {
+	return slices.Contains(h.cfg.Policy[c.GetString(roleKey)][object], method)
+}
+
+func (h *Handler) forbidden(c *gin.Context, object, method string) {
+	response.Error(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("role %s may not %s %s", c.GetString(roleKey), method, object))
+}
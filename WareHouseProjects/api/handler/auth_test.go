@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"WareHouseProjects/config"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/auth"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStorage serves the users and API keys of the middleware tests from
+// memory; every other call panics.
+type fakeStorage struct {
+	storage.StorageI
+	users fakeUsers
+	keys  fakeApiKeys
+}
+
+func (s fakeStorage) User() storage.UsersI     { return s.users }
+func (s fakeStorage) ApiKey() storage.ApiKeysI { return s.keys }
+
+// fakeUsers holds users by id.
+type fakeUsers struct {
+	storage.UsersI
+	users map[string]models.User
+}
+
+func (u fakeUsers) GetUser(_ context.Context, req *models.UserIdRequest) (*models.User, error) {
+	user, ok := u.users[req.Id]
+	if !ok {
+		return nil, storage.NewError(storage.ErrNotFound, "user not found", nil)
+	}
+	return &user, nil
+}
+
+// fakeApiKeys holds the user ids of API keys by the hash of the key.
+type fakeApiKeys struct {
+	storage.ApiKeysI
+	users map[string]string
+}
+
+func (k fakeApiKeys) GetApiKeyUser(_ context.Context, keyHash string) (string, error) {
+	userID, ok := k.users[keyHash]
+	if !ok {
+		return "", storage.NewError(storage.ErrNotFound, "api key not found", nil)
+	}
+	return userID, nil
+}
+
+const (
+	adminID   = "00000000-0000-0000-0000-00000000000a"
+	clerkID   = "00000000-0000-0000-0000-00000000000c"
+	deletedID = "00000000-0000-0000-0000-00000000000d"
+	branchID  = "00000000-0000-0000-0000-0000000000b1"
+)
+
+// authHandler returns a handler whose storage knows an admin of head office
+// and a clerk of branchID, and API keys "admin-key" of the admin and
+// "deleted-key" of a user that no longer exists.
+func authHandler() *Handler {
+	cfg := config.Load()
+	cfg.JWTSecret = "test secret"
+	strg := fakeStorage{
+		users: fakeUsers{users: map[string]models.User{
+			adminID: {ID: adminID, Username: "admin", Role: models.RoleAdmin},
+			clerkID: {ID: clerkID, Username: "clerk", Role: models.RoleClerk, Branch_id: branchID},
+		}},
+		keys: fakeApiKeys{users: map[string]string{
+			auth.HashAPIKey("admin-key"):   adminID,
+			auth.HashAPIKey("deleted-key"): deletedID,
+		}},
+	}
+	gin.SetMode(gin.TestMode)
+	return NewHandler(cfg, strg, logger.NewLogger("test", logger.LevelError))
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := authHandler()
+	token := func(secret, typ, userID string, ttl time.Duration) string {
+		t.Helper()
+		s, err := auth.NewToken([]byte(secret), typ, userID, "", ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantUser   string
+		wantBranch string
+	}{
+		{"api key", apiKeyHeader, "admin-key", http.StatusOK, adminID, ""},
+		{"unknown api key", apiKeyHeader, "other-key", http.StatusUnauthorized, "", ""},
+		{"api key of a deleted user", apiKeyHeader, "deleted-key", http.StatusUnauthorized, "", ""},
+		{"access token", "Authorization", "Bearer " + token("test secret", auth.AccessToken, adminID, time.Minute), http.StatusOK, adminID, ""},
+		{"access token of a clerk", "Authorization", "Bearer " + token("test secret", auth.AccessToken, clerkID, time.Minute), http.StatusOK, clerkID, branchID},
+		{"no credentials", "", "", http.StatusUnauthorized, "", ""},
+		{"another scheme", "Authorization", "Basic YWRtaW46YWRtaW4=", http.StatusUnauthorized, "", ""},
+		{"empty bearer", "Authorization", "Bearer ", http.StatusUnauthorized, "", ""},
+		{"expired token", "Authorization", "Bearer " + token("test secret", auth.AccessToken, adminID, -time.Minute), http.StatusUnauthorized, "", ""},
+		{"token of another secret", "Authorization", "Bearer " + token("other secret", auth.AccessToken, adminID, time.Minute), http.StatusUnauthorized, "", ""},
+		{"refresh token", "Authorization", "Bearer " + token("test secret", auth.RefreshToken, adminID, time.Minute), http.StatusUnauthorized, "", ""},
+		{"token of a deleted user", "Authorization", "Bearer " + token("test secret", auth.AccessToken, deletedID, time.Minute), http.StatusUnauthorized, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser, gotActor, gotBranch string
+			r := gin.New()
+			r.GET("/", h.Authenticate(), func(c *gin.Context) {
+				gotUser = c.GetString(userIDKey)
+				gotActor = storage.Actor(c.Request.Context())
+				gotBranch = storage.BranchScope(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if gotUser != tt.wantUser || gotActor != tt.wantUser {
+				t.Errorf("user = %q, actor = %q; want %q", gotUser, gotActor, tt.wantUser)
+			}
+			if gotBranch != tt.wantBranch {
+				t.Errorf("branch scope = %q, want %q", gotBranch, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	h := authHandler()
+
+	tests := []struct {
+		role   models.Role
+		method string
+		object string
+		want   int
+	}{
+		{models.RoleAdmin, http.MethodDelete, "api_key", http.StatusOK},
+		{models.RoleManager, http.MethodPut, "product", http.StatusOK},
+		{models.RoleManager, http.MethodGet, "user", http.StatusForbidden},
+		{models.RoleManager, http.MethodPost, "audit", http.StatusForbidden},
+		{models.RoleAuditor, http.MethodGet, "audit", http.StatusOK},
+		{models.RoleAuditor, http.MethodPost, "product", http.StatusForbidden},
+		{models.RoleAuditor, http.MethodGet, "api_key", http.StatusForbidden},
+		{models.RoleClerk, http.MethodDelete, "coming_table_product", http.StatusOK},
+		{models.RoleClerk, http.MethodPut, "remain", http.StatusOK},
+		{models.RoleClerk, http.MethodDelete, "remain", http.StatusForbidden},
+		{models.RoleClerk, http.MethodGet, "product", http.StatusOK},
+		{models.RoleClerk, http.MethodPost, "product", http.StatusForbidden},
+		{models.RoleClerk, http.MethodGet, "audit", http.StatusForbidden},
+		{"", http.MethodGet, "product", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+" "+tt.method+" "+tt.object, func(t *testing.T) {
+			r := gin.New()
+			r.Handle(tt.method, "/", func(c *gin.Context) {
+				c.Set(roleKey, string(tt.role))
+			}, h.Authorize(tt.object), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, "/", nil))
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	t.Run("unknown object", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Authorize did not panic")
+			}
+		}()
+		h.Authorize("products")
+	})
+}
@@ -3,9 +3,7 @@ package handler
 import (
 	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/logger"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,29 +13,31 @@ import (
 // @Summary      CREATES BRANCH
 // @Description  CREATES BRANCH BASED ON GIVEN DATA
 // @Tags         BRANCH
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        data  body      models.CreateBranch  true  "branch data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateBranch(c *gin.Context) {
 	var branch models.CreateBranch
-	err := c.ShouldBind(&branch)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
+	if !h.bind(c, &branch) {
 		return
 	}
 
-	resp, err := h.storage.Branch().CreateBranch(&branch)
+	resp, err := h.storage.Branch().CreateBranch(c.Request.Context(), &branch)
 	if err != nil {
-		h.log.Error("error Branch Create:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, "internal server error")
+		h.handleError(c, "error Branch Create:", err)
 		return
 	}
-	c.JSON(http.StatusCreated, response.CreateResponse{Message: "Succesfully created", Id: resp})
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
 }
 
 // Get branch godoc
@@ -45,24 +45,27 @@ func (h *Handler) CreateBranch(c *gin.Context) {
 // @Summary      GET BY ID
 // @Description  get branch by ID
 // @Tags         BRANCH
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Branch ID" format(uuid)
-// @Success      200  {object}  models.Branch
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.Branch}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetBranch(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Branch().GetBranch(&models.BranchIdRequest{Id: id})
+	resp, err := h.storage.Branch().GetBranch(c.Request.Context(), &models.BranchIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error Branch Get:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error Branch Get:", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetAllBranches godoc
@@ -70,42 +73,48 @@ func (h *Handler) GetBranch(c *gin.Context) {
 // @Summary      GET  ALL BRANCHES
 // @Description  get all branches based on limit, page and search by name
 // @Tags         BRANCH
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param   limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param   limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param   page         query     int        false  "page"          minimum(1)     default(1)
-// @Param   search         query     string        false  "search"
-// @Success      200  {object}  models.GetAllBranchRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        search          query     string    false  "search by name"
+// @Success      200  {object}  response.Response{data=[]models.Branch,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllBranch(c *gin.Context) {
 	h.log.Info("request GetAllBranch")
-	page, err := strconv.Atoi(c.DefaultQuery("page", "fmt.sprintf(`%d`,cfg.DefaultPage)"))
-	if err != nil {
-		h.log.Error("error getting page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	var req models.GetAllBranchRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		h.log.Error("error getting limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid limit param")
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "branches", &req.ListRequest, func() ([]models.Branch, string, error) {
+			resp, err := h.storage.Branch().GetAllBranch(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Branches, resp.NextCursor, nil
+		})
 		return
 	}
 
-	resp, err := h.storage.Branch().GetAllBranch(&models.GetAllBranchRequest{
-		Page:  page,
-		Limit: limit,
-		Name:  c.Query("search"),
-	})
+	resp, err := h.storage.Branch().GetAllBranch(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error Branch GetAllBranch:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, "internal server error")
+		h.handleError(c, "error Branch GetAllBranch:", err)
 		return
 	}
 	h.log.Warn("response to GetAllBranch")
-	c.JSON(http.StatusOK, resp)
+	response.List(c, http.StatusOK, resp.Branches, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
 }
 
 // UpdateBranch godoc
@@ -113,33 +122,35 @@ func (h *Handler) GetAllBranch(c *gin.Context) {
 // @Summary      UPDATE BRANCH BY ID
 // @Description  UPDATES BRANCH BASED ON GIVEN DATA AND ID
 // @Tags         BRANCH
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of branch" format(uuid)
 // @Param        data  body      models.UpdateBranch  true  "branch data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateBranch(ctx *gin.Context) {
 	var branch models.UpdateBranch
 
-	err := ctx.ShouldBind(&branch)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(ctx, &branch) {
 		return
 	}
 
 	branch.Id = ctx.Param("id")
-	resp, err := h.storage.Branch().UpdateBranch(&branch)
+	resp, err := h.storage.Branch().UpdateBranch(ctx.Request.Context(), &branch)
 	if err != nil {
-		h.log.Error("error branch update:", logger.Error(err))
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(ctx, "error branch update:", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(ctx, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteBranch godoc
@@ -147,22 +158,27 @@ func (h *Handler) UpdateBranch(ctx *gin.Context) {
 // @Summary      DELETE BRANCH BY ID
 // @Description  DELETES BRANCH BASED ON ID
 // @Tags         BRANCH
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of branch" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteBranch(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Branch().DeleteBranch(&models.BranchIdRequest{Id: id})
+	resp, err := h.storage.Branch().DeleteBranch(c.Request.Context(), &models.BranchIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting branch:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete branch"})
+		h.handleError(c, "error deleting branch:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Branch successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
 }
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBranchPrice godoc
+// @Router       /branch_price [POST]
+// @Summary      CREATE BRANCH PRICE
+// @Description  sets the price a branch sells a product at instead of the product price; a branch has one price per product
+// @Tags         branch_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateBranchPrice  true  "branch price data"
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateBranchPrice(c *gin.Context) {
+	var price models.CreateBranchPrice
+	if !h.bind(c, &price) {
+		return
+	}
+
+	resp, err := h.storage.BranchPrice().CreateBranchPrice(c.Request.Context(), &price)
+	if err != nil {
+		h.handleError(c, "error branch price create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
+}
+
+// GetBranchPrice godoc
+// @Router       /branch_price/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets branch price by ID
+// @Tags         branch_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "BranchPrice ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.BranchPrice}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetBranchPrice(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.BranchPrice().GetBranchPrice(c.Request.Context(), &models.BranchPriceIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get branch price:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllBranchPrice godoc
+// @Router       /branch_price [GET]
+// @Summary      LIST BRANCH PRICE
+// @Description  gets the branch prices, by branch or product
+// @Tags         branch_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: price, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        branch_id       query     string    false  "branch id" format(uuid)
+// @Param        product_id      query     string    false  "product id" format(uuid)
+// @Success      200  {object}  response.Response{data=[]models.BranchPrice,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllBranchPrice(c *gin.Context) {
+	var req models.GetAllBranchPriceRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "branch-prices", &req.ListRequest, func() ([]models.BranchPrice, string, error) {
+			resp, err := h.storage.BranchPrice().GetAllBranchPrice(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.BranchPrices, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.BranchPrice().GetAllBranchPrice(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error BranchPrice GetAllBranchPrice:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.BranchPrices, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// UpdateBranchPrice godoc
+// @Router       /branch_price/{id} [PUT]
+// @Summary      UPDATE BRANCH PRICE
+// @Description  changes the price of a branch price
+// @Tags         branch_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of branch price" format(uuid)
+// @Param        data  body      models.UpdateBranchPrice  true  "branch price data"
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) UpdateBranchPrice(c *gin.Context) {
+	var price models.UpdateBranchPrice
+	if !h.bind(c, &price) {
+		return
+	}
+
+	price.ID = c.Param("id")
+	resp, err := h.storage.BranchPrice().UpdateBranchPrice(c.Request.Context(), &price)
+	if err != nil {
+		h.handleError(c, "error branch price update:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
+}
+
+// DeleteBranchPrice godoc
+// @Router       /branch_price/{id} [DELETE]
+// @Summary      DELETE BRANCH PRICE BY ID
+// @Description  deletes a branch price; the branch sells the product at the product price again
+// @Tags         branch_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of branch price" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteBranchPrice(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.BranchPrice().DeleteBranchPrice(c.Request.Context(), &models.BranchPriceIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting branch price:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
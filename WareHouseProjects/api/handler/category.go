@@ -3,9 +3,7 @@ package handler
 import (
 	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/logger"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,29 +13,31 @@ import (
 // @Summary      CREATES Category
 // @Description  CREATES Category BASED ON GIVEN DATA
 // @Tags         category
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        data  body      models.CreateCategory  true  "Category data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateCategory(c *gin.Context) {
 	var category models.CreateCategory
-	err := c.ShouldBind(&category)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
+	if !h.bind(c, &category) {
 		return
 	}
 
-	resp, err := h.storage.Category().CreateCategory(&category)
+	resp, err := h.storage.Category().CreateCategory(c.Request.Context(), &category)
 	if err != nil {
-		h.log.Error("error Category Create:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, "internal server error")
+		h.handleError(c, "error Category Create:", err)
 		return
 	}
-	c.JSON(http.StatusCreated, response.CreateResponse{Message: "Succesfully created", Id: resp})
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
 }
 
 // Get category godoc
@@ -45,24 +45,27 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 // @Summary      GET BY ID
 // @Description  get category by ID
 // @Tags         category
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Category ID" format(uuid)
-// @Success      200  {object}  models.Category
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.Category}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetCategory(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Category().GetCategory(&models.CategoryIdRequest{Id: id})
+	resp, err := h.storage.Category().GetCategory(c.Request.Context(), &models.CategoryIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error Category Get:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error Category Get:", err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetAllCategories godoc
@@ -70,42 +73,49 @@ func (h *Handler) GetCategory(c *gin.Context) {
 // @Summary      GET  ALL CATEGORIES
 // @Description  get all categories based on limit, page and search by name
 // @Tags         category
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param   limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param   limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param   page         query     int        false  "page"          minimum(1)     default(1)
-// @Param   search         query     string        false  "search"
-// @Success      200  {object}  models.GetAllCategoryRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        search          query     string    false  "search by name"
+// @Param        parent_id       query     string    false  "parent category id" format(uuid)
+// @Success      200  {object}  response.Response{data=[]models.Category,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllCategory(c *gin.Context) {
 	h.log.Info("request GetAllCategory")
-	page, err := strconv.Atoi(c.DefaultQuery("page", "fmt.sprintf(`%d`,cfg.DefaultPage)"))
-	if err != nil {
-		h.log.Error("error getting page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	var req models.GetAllCategoryRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		h.log.Error("error getting limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid limit param")
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "categories", &req.ListRequest, func() ([]models.Category, string, error) {
+			resp, err := h.storage.Category().GetAllCategory(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Categories, resp.NextCursor, nil
+		})
 		return
 	}
 
-	resp, err := h.storage.Category().GetAllCategory(&models.GetAllCategoryRequest{
-		Page:  page,
-		Limit: limit,
-		Name:  c.Query("search"),
-	})
+	resp, err := h.storage.Category().GetAllCategory(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error Category GetAllCategory:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, "internal server error")
+		h.handleError(c, "error Category GetAllCategory:", err)
 		return
 	}
 	h.log.Warn("response to GetAllCategory")
-	c.JSON(http.StatusOK, resp)
+	response.List(c, http.StatusOK, resp.Categories, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
 }
 
 // UpdateCategory godoc
@@ -113,33 +123,35 @@ func (h *Handler) GetAllCategory(c *gin.Context) {
 // @Summary      UPDATE Category BY ID
 // @Description  UPDATES Category BASED ON GIVEN DATA AND ID
 // @Tags         category
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of category" format(uuid)
 // @Param        data  body      models.UpdateCategory true  "category data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateCategory(ctx *gin.Context) {
 	var category models.UpdateCategory
 
-	err := ctx.ShouldBind(&category)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(ctx, &category) {
 		return
 	}
 
 	category.Id = ctx.Param("id")
-	resp, err := h.storage.Category().UpdateCategory(&category)
+	resp, err := h.storage.Category().UpdateCategory(ctx.Request.Context(), &category)
 	if err != nil {
-		h.log.Error("error category update:", logger.Error(err))
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(ctx, "error category update:", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(ctx, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteCategory godoc
@@ -147,22 +159,27 @@ func (h *Handler) UpdateCategory(ctx *gin.Context) {
 // @Summary      DELETE CATEGORY BY ID
 // @Description  DELETES CATEGORY BASED ON ID
 // @Tags         category
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of category" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Category().DeleteCategory(&models.CategoryIdRequest{Id: id})
+	resp, err := h.storage.Category().DeleteCategory(c.Request.Context(), &models.CategoryIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting category:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		h.handleError(c, "error deleting category:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
 }
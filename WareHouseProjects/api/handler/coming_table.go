@@ -1,145 +1,202 @@
 package handler
 
 import (
+	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 // CreateComingTable godoc
 // @Router       /coming_table  [POST]
 // @Summary      CREATE ComingTable
 // @Description add ComingTable data to db based on given info in body
+// @Description A coming table in another currency than the base one takes the exchange rate of its date unless exchange_rate is given.
+// @Description Its coming_id is the next document number of its branch in the year of its date.
 // @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        data  body      models.CreateComingTable true  "ComingTable data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateComingTable(c *gin.Context) {
 	var coming_table models.CreateComingTable
-	err := c.ShouldBind(&coming_table)
-	if err != nil {
-		h.log.Error("error while binding coming table:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
+	if !h.bind(c, &coming_table) {
+		return
+	}
+	if err := h.documentCurrency(c.Request.Context(), &coming_table.Currency, &coming_table.ExchangeRate, coming_table.DateTime); err != nil {
+		h.handleError(c, "error Coming_Table create:", err)
 		return
 	}
 
-	resp, err := h.storage.Coming_Table().CreateComingTable(&coming_table)
+	var resp string
+	err := h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		var err error
+		coming_table.Coming_id, err = h.documentNumber(c.Request.Context(), strg, models.DocumentComingTable, coming_table.Branch_id, coming_table.DateTime)
+		if err != nil {
+			return err
+		}
+		resp, err = strg.Coming_Table().CreateComingTable(c.Request.Context(), &coming_table)
+		return err
+	})
 	if err != nil {
-		h.log.Error("error Coming_Table create:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error Coming_Table create:", err)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "success", "resp": resp})
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
 }
 
 // GetComingTable godoc
 // @Router       /coming_table/{id} [GET]
 // @Summary      GET BY ID
-// @Description  gets ComingTable by ID
+// @Description  gets ComingTable by ID with its lines, their totals and its net, tax and gross totals, also by tax rate
 // @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "ComingTable ID" format(uuid)
-// @Success      200  {object}  models.ComingTable
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.ComingTable}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetComingTable(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Coming_Table().GetComingTable(&models.ComingTableIdRequest{Id: id})
+	resp, err := h.storage.Coming_Table().GetComingTable(c.Request.Context(), &models.ComingTableIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get ComingTable:", err)
+		return
+	}
+	h.baseCurrency(resp)
+	resp.Lines, err = h.storage.Coming_TableProduct().GetComingTableById(c.Request.Context(), &models.ComingTableProductIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error get ComingTable:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error get ComingTable:", err)
+		return
+	}
+	resp.Taxes, err = h.storage.Coming_TableProduct().GetComingTableTaxes(c.Request.Context(), &models.ComingTableIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get ComingTable:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetAllComingTable godoc
 // @Router       /coming_table [GET]
 // @Summary      LIST Coming_Table
-// @Description  gets all Coming_Table based on limit, page and search by name
+// @Description  gets all Coming_Table based on limit, page and search by name, each with the totals of its lines
 // @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param  		 limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
-// @Param   	 coming_id        query     string     false  "coming_id"
-// @Param   	 branch_id        query     string     false  "branch_id"
-// @Success      200  {object}  models.GetAllComingTableRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: coming_id, date_time, status, line_count, total_price, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        coming_id       query     string    false  "search by coming id"
+// @Param        branch_id       query     string    false  "branch id" format(uuid)
+// @Param        status          query     string    false  "in_process or finished"
+// @Param        currency        query     string    false  "currency code" minlength(3) maxlength(3)
+// @Param        date_from       query     string    false  "date_time from, YYYY-MM-DD"
+// @Param        date_to         query     string    false  "date_time to (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  response.Response{data=[]models.ComingTable,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllComingTable(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		h.log.Error("error get page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	var req models.GetAllComingTableRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		h.log.Error("error get limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	h.pageLimit(&req.Limit)
+	req.InBaseCurrency = req.Currency == h.cfg.BaseCurrency
+
+	if req.Export != "" {
+		exportList(h, c, "coming_tables", &req.ListRequest, func() ([]models.ComingTable, string, error) {
+			resp, err := h.storage.Coming_Table().GetAllComingTable(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			for i := range resp.ComingTables {
+				h.baseCurrency(&resp.ComingTables[i])
+			}
+			return resp.ComingTables, resp.NextCursor, nil
+		})
 		return
 	}
 
-	resp, err := h.storage.Coming_Table().GetAllComingTable(&models.GetAllComingTableRequest{
-		Page:     page,
-		Limit:    limit,
-		ComingID: c.Query("search"),
-		BranchID: c.Query("search"),
-	})
+	resp, err := h.storage.Coming_Table().GetAllComingTable(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error ComingTable GetAllComingTable:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error ComingTable GetAllComingTable:", err)
 		return
 	}
+	for i := range resp.ComingTables {
+		h.baseCurrency(&resp.ComingTables[i])
+	}
 
-	c.JSON(http.StatusOK, resp)
+	response.List(c, http.StatusOK, resp.ComingTables, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
 }
 
 // UpdateComingTable godoc
 // @Router       /coming_table/{id} [PUT]
 // @Summary      UPDATE COMINGTABLE
 // @Description  UPDATES COMINGTABLE BASED ON GIVEN DATA AND ID
+// @Description  The costs of its lines are converted again at the new exchange rate. Only a coming table in process can change, and its branch and the year of its date stay those it was numbered in.
 // @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of ComingTable" format(uuid)
 // @Param        data  body      models.UpdateComingTable  true  "ComingTable data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateComingTable(c *gin.Context) {
 	var ComingTable models.UpdateComingTable
 
-	err := c.ShouldBind(&ComingTable)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(c, &ComingTable) {
 		return
 	}
 
 	ComingTable.ID = c.Param("id")
-	resp, err := h.storage.Coming_Table().UpdateComingTable(&ComingTable)
+	if err := h.documentCurrency(c.Request.Context(), &ComingTable.Currency, &ComingTable.ExchangeRate, ComingTable.DateTime); err != nil {
+		h.handleError(c, "error ComingTable update:", err)
+		return
+	}
+	resp, err := h.storage.Coming_Table().UpdateComingTable(c.Request.Context(), &ComingTable)
 	if err != nil {
-		h.log.Error("error ComingTable update:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(c, "error ComingTable update:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteComingTable godoc
@@ -147,22 +204,54 @@ func (h *Handler) UpdateComingTable(c *gin.Context) {
 // @Summary      DELETE ComingTable BY ID
 // @Description  deletes ComingTable by id
 // @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of ComingTable" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteComingTable(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Coming_Table().DeleteComingTable(&models.ComingTableIdRequest{Id: id})
+	resp, err := h.storage.Coming_Table().DeleteComingTable(c.Request.Context(), &models.ComingTableIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting ComingTable:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ComingTable"})
+		h.handleError(c, "error deleting ComingTable:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ComingTable successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
+
+// documentCurrency settles the currency and exchange rate of a coming table
+// dated dateTime. The base currency is stored empty at rate 1; another
+// currency without a rate takes the one in force on that date.
+func (h *Handler) documentCurrency(ctx context.Context, currency *string, rate *decimal.Decimal, dateTime string) error {
+	if *currency == "" || *currency == h.cfg.BaseCurrency {
+		*currency, *rate = "", decimal.NewFromInt(1)
+		return nil
+	}
+	if !rate.IsZero() {
+		return nil
+	}
+
+	found, err := h.storage.ExchangeRate().GetExchangeRateOn(ctx, &models.ExchangeRateOn{Currency: *currency, Date: dateTime[:len(time.DateOnly)]})
+	if err != nil {
+		return err
+	}
+	*rate = found.Rate
+	return nil
+}
+
+// baseCurrency names the base currency of a coming table stored without one.
+func (h *Handler) baseCurrency(table *models.ComingTable) {
+	if table.Currency == "" {
+		table.Currency = h.cfg.BaseCurrency
+	}
 }
@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 // CreateComingTableProduct godoc
@@ -14,127 +19,157 @@ import (
 // @Summary      CREATE ComingTableProduct
 // @Description add ComingTableProduct data to db based on given info in body
 // @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        data  body      models.CreateComingTableProductSwagger true  "ComingTableProduct data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateComingTableProduct(c *gin.Context) {
 	var coming_tableProduct models.CreateComingTableProduct
-	err := c.ShouldBind(&coming_tableProduct)
-	if err != nil {
-		h.log.Error("error while binding coming table_product:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
+	if !h.bind(c, &coming_tableProduct) {
 		return
 	}
 
-	//check status
-	comingTableId := c.Param("coming_table_id")
-	coming_table_id := models.ComingTableIdRequest{Id: comingTableId}
-	_, err = h.storage.Coming_Table().GetStatus(&coming_table_id)
+	var (
+		resp    string
+		created bool
+	)
+	err := h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		//check status
+		coming_table_id := models.ComingTableIdRequest{Id: coming_tableProduct.Coming_Table_id}
+		if _, err := strg.Coming_Table().GetStatus(c.Request.Context(), &coming_table_id); err != nil {
+			return fmt.Errorf("getting coming table status: %w", err)
+		}
+
+		var err error
+		resp, created, err = h.addComingTableProduct(c.Request.Context(), strg, &coming_tableProduct)
+		return err
+	})
 	if err != nil {
-		h.log.Error("error getting coming table status:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error Coming_Table_Product create:", err)
 		return
 	}
 
-	//get  product details
-	CheckBarcodeComingTable := models.CheckBarcodeComingTable{Barcode: coming_tableProduct.Barcode, Coming_Table_id: coming_tableProduct.Coming_Table_id}
-	respondProduct, err := h.storage.Product().GetProductByBarcode(&CheckBarcodeComingTable)
-	if err != nil {
-		h.log.Error("error Getting Product info:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if created {
+		response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
 		return
 	}
-	coming_tableProduct.Name = respondProduct.Name
-	coming_tableProduct.Price = respondProduct.Price
-	coming_tableProduct.Category_id = respondProduct.Category_id
-	coming_tableProduct.TotalPrice = respondProduct.Price * coming_tableProduct.Count
-
-	barcodeQuery := c.Query("barcode")
-	barcode := models.CheckBarcodeComingTable{Barcode: barcodeQuery, Coming_Table_id: comingTableId}
-	id, err := h.storage.Coming_TableProduct().CheckAviableProduct(&barcode)
+	response.OK(c, http.StatusOK, "updated existing coming table product", response.IdResponse{Id: resp})
+}
 
+// addComingTableProduct fills line from the product with its barcode and adds
+// it to its coming table: a new line is created, or the count and total of the
+// line already holding the barcode are increased. The cost of line is in the
+// currency of the coming table and is converted to the base currency at its
+// exchange rate. A line without a cost costs what the product cost last time,
+// or its selling price if it never arrived. A new line is taxed at the
+// current tax rate of the product. It reports whether a line was
+// created. The caller checks that the coming table is still in process.
+func (h *Handler) addComingTableProduct(ctx context.Context, strg storage.StorageI, line *models.CreateComingTableProduct) (string, bool, error) {
+	doc, err := strg.Coming_Table().GetComingTable(ctx, &models.ComingTableIdRequest{Id: line.Coming_Table_id})
 	if err != nil {
-		h.log.Error("error   id or barcode not found in comingTable", logger.Error(err))
-		// if this product didnt exist Add it
-		resp, err := h.storage.Coming_TableProduct().CreateComingTableProduct(&coming_tableProduct)
-		if err != nil {
-			h.log.Error("error Coming_Table_Product create:", logger.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+		return "", false, fmt.Errorf("getting coming table: %w", err)
+	}
 
-		}
+	//get  product details
+	CheckBarcodeComingTable := models.CheckBarcodeComingTable{Barcode: line.Barcode, Coming_Table_id: line.Coming_Table_id}
+	respondProduct, err := strg.Product().GetProductByBarcode(ctx, &CheckBarcodeComingTable)
+	if err != nil {
+		return "", false, fmt.Errorf("getting product info: %w", err)
+	}
+	line.Name = respondProduct.Name
+	line.Price = respondProduct.Price
+	line.Category_id = respondProduct.Category_id
+	line.TaxRate = respondProduct.TaxRate
+	lineCosts(line, respondProduct, doc.ExchangeRate)
 
-		c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "successfully added ", "resp": resp})
-		return
+	id, err := strg.Coming_TableProduct().CheckAviableProduct(ctx, &CheckBarcodeComingTable)
+	if errors.Is(err, storage.ErrNotFound) {
+		h.log.Info("barcode not found in coming table, adding it", logger.String("barcode", line.Barcode))
+		// if this product didnt exist Add it
+		id, err = strg.Coming_TableProduct().CreateComingTableProduct(ctx, line)
+		return id, true, err
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("checking coming table product: %w", err)
 	}
+
 	updatingData := models.UpdateComingTableProduct{
 		ID:              id,
-		Category_id:     coming_tableProduct.Category_id,
-		Name:            coming_tableProduct.Name,
-		Price:           coming_tableProduct.Price,
-		Barcode:         coming_tableProduct.Barcode,
-		Count:           coming_tableProduct.Count,
-		TotalPrice:      coming_tableProduct.TotalPrice,
-		Coming_Table_id: coming_tableProduct.Coming_Table_id,
+		Category_id:     line.Category_id,
+		Name:            line.Name,
+		Price:           line.Price,
+		Cost:            line.Cost,
+		DocCost:         line.DocCost,
+		Barcode:         line.Barcode,
+		Count:           line.Count,
+		TotalPrice:      line.TotalPrice,
+		DocTotalPrice:   line.DocTotalPrice,
+		Coming_Table_id: line.Coming_Table_id,
 	}
-
-	resp, err := h.storage.Coming_TableProduct().UpdateIdAviable(&updatingData)
-	if err != nil {
-		h.log.Error("error Updating  coming_table_product:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-
+	if _, err := strg.Coming_TableProduct().UpdateIdAviable(ctx, &updatingData); err != nil {
+		return "", false, err
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated existing coming_product_table", "resp": resp})
+	return id, false, nil
 }
 
-/*
-func (h *Handler) CreateComingTableProduct(c *gin.Context) {
-	var coming_tableProduct models.CreateComingTableProduct
-	err := c.ShouldBind(&coming_tableProduct)
-	if err != nil {
-		h.log.Error("error while binding coming table_product:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
-		return
-	}
-
-	resp, err := h.storage.Coming_TableProduct().CreateComingTableProduct(&coming_tableProduct)
-	if err != nil {
-		h.log.Error("error Coming_Table_Product create:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+// lineCosts fills the costs and totals of line, in the currency of its coming
+// table, which is worth exchangeRate, and in the base currency. A cost given
+// with line is in the currency of the coming table; without one the line
+// costs what product cost last time, or its selling price, converted to the
+// currency of the coming table and rounded to cents. The amounts in the base
+// currency are those in the currency of the coming table times exchangeRate,
+// as UpdateComingTable converts them again.
+func lineCosts(line *models.CreateComingTableProduct, product *models.RespBarcodeProduct, exchangeRate decimal.Decimal) {
+	line.DocCost = line.Cost
+	if line.Cost.IsZero() {
+		line.DocCost = product.Price
+		if product.Cost != nil {
+			line.DocCost = *product.Cost
+		}
+		if !exchangeRate.Equal(decimal.NewFromInt(1)) {
+			line.DocCost = line.DocCost.DivRound(exchangeRate, 2)
+		}
 	}
-	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "success", "resp": resp})
+	line.Cost = line.DocCost.Mul(exchangeRate)
+	line.DocTotalPrice = line.DocCost.Mul(line.Count)
+	line.TotalPrice = line.DocTotalPrice.Mul(exchangeRate)
 }
-*/
 
 // GetComingTableProduct godoc
 // @Router       /coming_table_product/{id} [GET]
 // @Summary      GET BY ID
 // @Description  gets ComingTableProduct by ID
 // @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "ComingTableProduct ID" format(uuid)
-// @Success      200  {object}  models.ComingTableProduct
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.ComingTableProduct}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetComingTableProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Coming_TableProduct().GetComingTableProduct(&models.ComingTableProductIdRequest{Id: id})
+	resp, err := h.storage.Coming_TableProduct().GetComingTableProduct(c.Request.Context(), &models.ComingTableProductIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error get ComingTableProduct:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error get ComingTableProduct:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetAllComingTableProduct godoc
@@ -142,43 +177,52 @@ func (h *Handler) GetComingTableProduct(c *gin.Context) {
 // @Summary      LIST Coming_Table_Product
 // @Description  gets all Coming_TableProduct based on limit, page and search by name
 // @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param  		 limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
-// @Param   	 category_id   query     string     false  "category_id"
-// @Param   	 barcode       query     string     false  "barcode"
-// @Success      200  {object}  models.GetAllComingTableProductRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, price, cost, barcode, count, total_price, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        coming_table_id query     string    false  "coming table id" format(uuid)
+// @Param        category_id     query     string    false  "category id" format(uuid)
+// @Param        barcode         query     string    false  "exact barcode"
+// @Param        name            query     string    false  "search by name"
+// @Param        price_from      query     number    false  "minimum price"
+// @Param        price_to        query     number    false  "maximum price"
+// @Success      200  {object}  response.Response{data=[]models.ComingTableProduct,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllComingTableProduct(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		h.log.Error("error get page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	var req models.GetAllComingTableProductRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		h.log.Error("error get limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "coming_table_products", &req.ListRequest, func() ([]models.ComingTableProduct, string, error) {
+			resp, err := h.storage.Coming_TableProduct().GetAllComingTableProduct(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.ComingTableProducts, resp.NextCursor, nil
+		})
 		return
 	}
 
-	resp, err := h.storage.Coming_TableProduct().GetAllComingTableProduct(&models.GetAllComingTableProductRequest{
-		Page:        page,
-		Limit:       limit,
-		Category_id: c.Query("search"),
-		Barcode:     c.Query("search"),
-	})
+	resp, err := h.storage.Coming_TableProduct().GetAllComingTableProduct(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error ComingTableProduct GetAllComingTableProduct:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error ComingTableProduct GetAllComingTableProduct:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.List(c, http.StatusOK, resp.ComingTableProducts, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
 }
 
 // UpdateComingTableProduct godoc
@@ -186,33 +230,35 @@ func (h *Handler) GetAllComingTableProduct(c *gin.Context) {
 // @Summary      UPDATE COMINGTableProduct
 // @Description  UPDATES COMINGTableProduct BASED ON GIVEN DATA AND ID
 // @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of ComingTableProduct" format(uuid)
 // @Param        data  body      models.UpdateComingTableProduct  true  "ComingTableProduct data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateComingTableProduct(c *gin.Context) {
 	var ComingTableProduct models.UpdateComingTableProduct
 
-	err := c.ShouldBind(&ComingTableProduct)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(c, &ComingTableProduct) {
 		return
 	}
 
 	ComingTableProduct.ID = c.Param("id")
-	resp, err := h.storage.Coming_TableProduct().UpdateComingTableProduct(&ComingTableProduct)
+	resp, err := h.storage.Coming_TableProduct().UpdateComingTableProduct(c.Request.Context(), &ComingTableProduct)
 	if err != nil {
-		h.log.Error("error ComingTableProduct update:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(c, "error ComingTableProduct update:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteComingTableProduct godoc
@@ -220,22 +266,27 @@ func (h *Handler) UpdateComingTableProduct(c *gin.Context) {
 // @Summary      DELETE ComingTableProduct BY ID
 // @Description  deletes ComingTableProduct by id
 // @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of ComingTableProduct" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteComingTableProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Coming_TableProduct().DeleteComingTableProduct(&models.ComingTableProductIdRequest{Id: id})
+	resp, err := h.storage.Coming_TableProduct().DeleteComingTableProduct(c.Request.Context(), &models.ComingTableProductIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting ComingTableProduct:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ComingTableProduct"})
+		h.handleError(c, "error deleting ComingTableProduct:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ComingTableProduct successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
 }
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestLineCosts(t *testing.T) {
+	lastCost := dec("0.1")
+	averageCost := dec("0.1875")
+
+	tests := []struct {
+		name         string
+		cost         string
+		count        string
+		product      models.RespBarcodeProduct
+		exchangeRate string
+		wantCost     string
+		wantDocCost  string
+		wantTotal    string
+		wantDocTotal string
+	}{
+		{
+			name:         "given cost in base currency",
+			cost:         "0.1",
+			count:        "3",
+			exchangeRate: "1",
+			wantCost:     "0.1",
+			wantDocCost:  "0.1",
+			wantTotal:    "0.3",
+			wantDocTotal: "0.3",
+		},
+		{
+			name:         "cost and count with decimals",
+			cost:         "0.2",
+			count:        "0.1",
+			exchangeRate: "1",
+			wantCost:     "0.2",
+			wantDocCost:  "0.2",
+			wantTotal:    "0.02",
+			wantDocTotal: "0.02",
+		},
+		{
+			name:         "many units of a cent",
+			cost:         "0.01",
+			count:        "1000000",
+			exchangeRate: "1",
+			wantCost:     "0.01",
+			wantDocCost:  "0.01",
+			wantTotal:    "10000",
+			wantDocTotal: "10000",
+		},
+		{
+			name:         "given cost in another currency",
+			cost:         "1.15",
+			count:        "7",
+			exchangeRate: "12650.35",
+			wantCost:     "14547.9025",
+			wantDocCost:  "1.15",
+			wantTotal:    "101835.3175",
+			wantDocTotal: "8.05",
+		},
+		{
+			name:         "last cost of the product",
+			count:        "3",
+			product:      models.RespBarcodeProduct{Price: dec("0.5"), Cost: &lastCost},
+			exchangeRate: "1",
+			wantCost:     "0.1",
+			wantDocCost:  "0.1",
+			wantTotal:    "0.3",
+			wantDocTotal: "0.3",
+		},
+		{
+			name:         "selling price of a product never received",
+			count:        "3",
+			product:      models.RespBarcodeProduct{Price: dec("0.7")},
+			exchangeRate: "1",
+			wantCost:     "0.7",
+			wantDocCost:  "0.7",
+			wantTotal:    "2.1",
+			wantDocTotal: "2.1",
+		},
+		{
+			name:         "last cost with more decimals than cents",
+			count:        "2",
+			product:      models.RespBarcodeProduct{Price: dec("0.5"), Cost: &averageCost},
+			exchangeRate: "1",
+			wantCost:     "0.1875",
+			wantDocCost:  "0.1875",
+			wantTotal:    "0.375",
+			wantDocTotal: "0.375",
+		},
+		{
+			name:         "last cost converted to another currency",
+			count:        "3",
+			product:      models.RespBarcodeProduct{Price: dec("20000"), Cost: &lastCost},
+			exchangeRate: "0.3",
+			wantCost:     "0.099",
+			wantDocCost:  "0.33",
+			wantTotal:    "0.297",
+			wantDocTotal: "0.99",
+		},
+		{
+			name:         "selling price converted to another currency",
+			count:        "7",
+			product:      models.RespBarcodeProduct{Price: dec("20000")},
+			exchangeRate: "12650.35",
+			wantCost:     "19987.553",
+			wantDocCost:  "1.58",
+			wantTotal:    "139912.871",
+			wantDocTotal: "11.06",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := models.CreateComingTableProduct{Count: dec(tt.count)}
+			if tt.cost != "" {
+				line.Cost = dec(tt.cost)
+			}
+
+			lineCosts(&line, &tt.product, dec(tt.exchangeRate))
+
+			for _, c := range []struct {
+				field string
+				got   decimal.Decimal
+				want  string
+			}{
+				{"cost", line.Cost, tt.wantCost},
+				{"doc cost", line.DocCost, tt.wantDocCost},
+				{"total", line.TotalPrice, tt.wantTotal},
+				{"doc total", line.DocTotalPrice, tt.wantDocTotal},
+			} {
+				if c.got.String() != c.want {
+					t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+				}
+			}
+			if !line.DocTotalPrice.Mul(dec(tt.exchangeRate)).Equal(line.TotalPrice) {
+				t.Errorf("doc total %s at %s is not the total %s", line.DocTotalPrice, tt.exchangeRate, line.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestCreateComingTableProduct(t *testing.T) {
+	h, strg := testHandler(t)
+	ctx := context.Background()
+	id := newComingTable(t, strg)
+
+	taxRateID, err := strg.TaxRate().CreateTaxRate(ctx, &models.CreateTaxRate{Name: unique(), Rate: dec("12")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	barcode := uuid.NewString()
+	if _, err := strg.Product().CreateProduct(ctx, &models.CreateProduct{Name: "Milk", Price: dec("20000"), Barcode: barcode, Tax_rate_id: taxRateID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scan := range []struct {
+		count      string
+		wantStatus int
+	}{
+		{"2", http.StatusCreated},
+		{"1", http.StatusOK},
+	} {
+		body := fmt.Sprintf(`{"barcode":%q,"coming_table_id":%q,"count":%s,"cost":1.5}`, barcode, id, scan.count)
+		w := serve(h.CreateComingTableProduct, http.MethodPost, "/coming_table_product", strings.NewReader(body))
+		if w.Code != scan.wantStatus {
+			t.Fatalf("status = %d, want %d: %s", w.Code, scan.wantStatus, w.Body)
+		}
+	}
+
+	lines, err := strg.Coming_TableProduct().GetComingTableById(ctx, &models.ComingTableProductIdRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("%d lines, want the two scans in one", len(lines))
+	}
+	line := lines[0]
+	if line.Name != "Milk" || line.Barcode != barcode {
+		t.Errorf("line is %s %s, want Milk %s", line.Name, line.Barcode, barcode)
+	}
+	for _, c := range []struct {
+		field string
+		got   decimal.Decimal
+		want  string
+	}{
+		{"count", line.Count, "3"},
+		{"price", line.Price, "20000"},
+		{"cost", line.Cost, "18900"},
+		{"doc cost", line.DocCost, "1.5"},
+		{"total", line.TotalPrice, "56700"},
+		{"doc total", line.DocTotalPrice, "4.5"},
+		{"tax rate", line.TaxRate, "12"},
+	} {
+		if !c.got.Equal(dec(c.want)) {
+			t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"WareHouseProjects/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newComingTable creates a branch and an empty coming table of it in USD, and
+// returns the id of the coming table.
+func newComingTable(t *testing.T, strg storage.StorageI) string {
+	t.Helper()
+	ctx := context.Background()
+
+	code := unique()
+	branchID, err := strg.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := strg.Coming_Table().CreateComingTable(ctx, &models.CreateComingTable{Coming_id: code + "-IN-2026-000001", Branch_id: branchID, DateTime: "2026-03-05 14:30:00", Currency: "USD", ExchangeRate: dec("12600")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// addLines adds a taxed and an untaxed line to the coming table id.
+func addLines(t *testing.T, strg storage.StorageI, id string) {
+	t.Helper()
+
+	for _, l := range []models.CreateComingTableProduct{
+		{Name: "Milk", Barcode: uuid.NewString(), Price: dec("20000"), Count: dec("2"), Cost: dec("18900"), DocCost: dec("1.5"), TotalPrice: dec("37800"), DocTotalPrice: dec("3"), TaxRate: dec("12")},
+		{Name: "Bread", Barcode: uuid.NewString(), Price: dec("60000"), Count: dec("0.5"), Cost: dec("50400"), DocCost: dec("4"), TotalPrice: dec("25200"), DocTotalPrice: dec("2"), TaxRate: dec("0")},
+	} {
+		l.Coming_Table_id = id
+		if _, err := strg.Coming_TableProduct().CreateComingTableProduct(context.Background(), &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetComingTable(t *testing.T) {
+	h, strg := testHandler(t)
+	id := newComingTable(t, strg)
+	addLines(t, strg, id)
+
+	w := serve(h.GetComingTable, http.MethodGet, "/coming_table/"+id, nil, gin.Param{Key: "id", Value: id})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		response.Response
+		Data models.ComingTable `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	doc := resp.Data
+
+	if doc.DateTime != "2026-03-05 14:30:00" || doc.Currency != "USD" || doc.Status != models.InProcess {
+		t.Errorf("coming table = %s, %s, %s; want 2026-03-05 14:30:00, USD, in_process", doc.DateTime, doc.Currency, doc.Status)
+	}
+	if doc.LineCount != 2 || len(doc.Lines) != 2 {
+		t.Errorf("line count = %d with %d lines, want 2", doc.LineCount, len(doc.Lines))
+	}
+	for _, c := range []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"total count", doc.TotalCount.String(), "2.5"},
+		{"total", doc.TotalPrice.String(), "63000"},
+		{"doc total", doc.DocTotalPrice.String(), "5"},
+		{"net", doc.NetAmount.String(), "63000"},
+		{"tax", doc.TaxAmount.String(), "4536"},
+		{"gross", doc.GrossAmount.String(), "67536"},
+	} {
+		if !dec(c.got).Equal(dec(c.want)) {
+			t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+		}
+	}
+	if len(doc.Taxes) != 2 || !doc.Taxes[1].TaxAmount.Equal(dec("4536")) {
+		t.Errorf("taxes = %+v, want 0%% and 12%% with a tax of 4536", doc.Taxes)
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/storage"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// documentNumber takes the next number of a document of docType issued by
+// branchID on dateTime and lays it out as DOCUMENT_NUMBER_FORMAT says. strg
+// must be the transaction that stores the document.
+func (h *Handler) documentNumber(ctx context.Context, strg storage.StorageI, docType models.DocumentType, branchID, dateTime string) (string, error) {
+	date, err := time.Parse(time.DateTime, dateTime)
+	if err != nil {
+		return "", storage.NewError(storage.ErrValidation, "invalid date_time", err)
+	}
+
+	number, err := strg.DocumentNumber().NextDocumentNumber(ctx, &models.NextDocumentNumber{
+		BranchID: branchID,
+		Type:     docType,
+		Year:     date.Year(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("taking document number: %w", err)
+	}
+
+	return strings.NewReplacer(
+		"{branch}", number.BranchCode,
+		"{type}", string(number.Type),
+		"{year}", strconv.Itoa(number.Year),
+		"{number}", fmt.Sprintf("%0*d", h.cfg.DocumentNumberDigits, number.Number),
+	).Replace(h.cfg.DocumentNumberFormat), nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in response.Response.Code.
+const (
+	CodeBadRequest   = "BAD_REQUEST"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeValidation   = "VALIDATION_ERROR"
+	CodeInvalidState = "INVALID_STATE"
+	CodeTimeout      = "TIMEOUT"
+	CodeInternal     = "INTERNAL_ERROR"
+)
+
+// handleError logs err under msg and answers with the status and code that
+// match its storage error kind. Unknown errors become a 500 whose message does
+// not leak internals.
+func (h *Handler) handleError(c *gin.Context, msg string, err error) {
+	status, code := http.StatusInternalServerError, CodeInternal
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		status, code = http.StatusNotFound, CodeNotFound
+	case errors.Is(err, storage.ErrConflict):
+		status, code = http.StatusConflict, CodeConflict
+	case errors.Is(err, storage.ErrValidation):
+		status, code = http.StatusUnprocessableEntity, CodeValidation
+	case errors.Is(err, storage.ErrInvalidState):
+		status, code = http.StatusConflict, CodeInvalidState
+	case errors.Is(err, storage.ErrForbidden):
+		status, code = http.StatusForbidden, CodeForbidden
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code = http.StatusGatewayTimeout, CodeTimeout
+	}
+
+	message := http.StatusText(status)
+	var domainErr *storage.Error
+	if errors.As(err, &domainErr) {
+		message = domainErr.Message
+	}
+
+	if status >= http.StatusInternalServerError {
+		h.log.Error(msg, logger.Error(err))
+	} else {
+		h.log.Warn(msg, logger.Error(err))
+	}
+
+	response.Error(c, status, code, message)
+}
+
+// badRequest answers with a 400 for input that could not be parsed at all.
+func (h *Handler) badRequest(c *gin.Context, message string, err error) {
+	h.log.Error(message, logger.Error(err))
+	response.Error(c, http.StatusBadRequest, CodeBadRequest, message)
+}
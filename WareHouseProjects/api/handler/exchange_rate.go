@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateExchangeRate godoc
+// @Router       /exchange_rate [POST]
+// @Summary      CREATE EXCHANGE RATE
+// @Description  sets how many units of the base currency one unit of currency is worth from date on; a currency has one rate per day
+// @Tags         exchange_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateExchangeRate  true  "exchange rate data"
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateExchangeRate(c *gin.Context) {
+	var rate models.CreateExchangeRate
+	if !h.bind(c, &rate) {
+		return
+	}
+	if !h.foreignCurrency(c, rate.Currency) {
+		return
+	}
+
+	resp, err := h.storage.ExchangeRate().CreateExchangeRate(c.Request.Context(), &rate)
+	if err != nil {
+		h.handleError(c, "error exchange rate create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
+}
+
+// GetExchangeRate godoc
+// @Router       /exchange_rate/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets exchange rate by ID
+// @Tags         exchange_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ExchangeRate ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.ExchangeRate}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetExchangeRate(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.ExchangeRate().GetExchangeRate(c.Request.Context(), &models.ExchangeRateIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get exchange rate:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllExchangeRate godoc
+// @Router       /exchange_rate [GET]
+// @Summary      LIST EXCHANGE RATE
+// @Description  gets the exchange rates, by currency and date
+// @Tags         exchange_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: currency, date, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        currency        query     string    false  "currency code" minlength(3) maxlength(3)
+// @Param        date_from       query     string    false  "date from, YYYY-MM-DD"
+// @Param        date_to         query     string    false  "date to (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  response.Response{data=[]models.ExchangeRate,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllExchangeRate(c *gin.Context) {
+	var req models.GetAllExchangeRateRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "exchange-rates", &req.ListRequest, func() ([]models.ExchangeRate, string, error) {
+			resp, err := h.storage.ExchangeRate().GetAllExchangeRate(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.ExchangeRates, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.ExchangeRate().GetAllExchangeRate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error ExchangeRate GetAllExchangeRate:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.ExchangeRates, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// UpdateExchangeRate godoc
+// @Router       /exchange_rate/{id} [PUT]
+// @Summary      UPDATE EXCHANGE RATE
+// @Description  changes an exchange rate; coming tables keep the rate they were converted at
+// @Tags         exchange_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of exchange rate" format(uuid)
+// @Param        data  body      models.UpdateExchangeRate  true  "exchange rate data"
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) UpdateExchangeRate(c *gin.Context) {
+	var rate models.UpdateExchangeRate
+	if !h.bind(c, &rate) {
+		return
+	}
+
+	if !h.foreignCurrency(c, rate.Currency) {
+		return
+	}
+
+	rate.ID = c.Param("id")
+	resp, err := h.storage.ExchangeRate().UpdateExchangeRate(c.Request.Context(), &rate)
+	if err != nil {
+		h.handleError(c, "error exchange rate update:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
+}
+
+// DeleteExchangeRate godoc
+// @Router       /exchange_rate/{id} [DELETE]
+// @Summary      DELETE EXCHANGE RATE BY ID
+// @Description  deletes an exchange rate; coming tables keep the rate they were converted at
+// @Tags         exchange_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of exchange rate" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteExchangeRate(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.ExchangeRate().DeleteExchangeRate(c.Request.Context(), &models.ExchangeRateIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting exchange rate:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
+
+// foreignCurrency answers with a 422 when currency is the base currency,
+// whose rate is always 1.
+func (h *Handler) foreignCurrency(c *gin.Context, currency string) bool {
+	if currency != h.cfg.BaseCurrency {
+		return true
+	}
+
+	response.Error(c, http.StatusUnprocessableEntity, CodeValidation, "request validation failed", response.FieldError{Field: "currency", Reason: "is the base currency, whose rate is always 1"})
+	return false
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/pkg/sheet"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// exportPageSize is the number of rows an export reads from storage at once.
+const exportPageSize = 500
+
+// exportList answers with every row of a list as a CSV or XLSX file. fetch
+// reads one page of req; it is called with the cursor of each following page
+// until the last one, so the whole list is never held in memory. Columns are
+// the JSON fields of T.
+func exportList[T any](h *Handler, c *gin.Context, name string, req *models.ListRequest, fetch func() ([]T, string, error)) {
+	format := sheet.Format(req.Export)
+	req.Page, req.Limit, req.Cursor = 1, exportPageSize, ""
+
+	// The first page is read before anything is written, so that errors can
+	// still be answered with the usual envelope.
+	items, next, err := fetch()
+	if err != nil {
+		h.handleError(c, "error "+name+" export:", err)
+		return
+	}
+
+	c.Header("Content-Type", sheet.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102"), format))
+
+	w, err := sheet.NewWriter(format, c.Writer)
+	if err == nil {
+		err = w.Write(exportHeader(reflect.TypeOf(items).Elem()))
+	}
+	for err == nil {
+		for _, item := range items {
+			if err = w.Write(exportRow(reflect.ValueOf(item))); err != nil {
+				break
+			}
+		}
+		if err != nil || next == "" {
+			break
+		}
+
+		req.Cursor = next
+		items, next, err = fetch()
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		// The status line is gone already; all that is left is to cut the
+		// file short.
+		h.log.Error("error "+name+" export:", logger.Error(err))
+		c.Abort()
+	}
+}
+
+// exportHeader returns the JSON names of the fields of t. Nested lists do not
+// fit a row and are left out.
+func exportHeader(t reflect.Type) []interface{} {
+	header := make([]interface{}, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := exportName(t.Field(i)); ok {
+			header = append(header, name)
+		}
+	}
+	return header
+}
+
+// exportRow returns the fields of v in the order of exportHeader.
+func exportRow(v reflect.Value) []interface{} {
+	row := make([]interface{}, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		if _, ok := exportName(v.Type().Field(i)); !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				row = append(row, "")
+				continue
+			}
+			field = field.Elem()
+		}
+		// Decimals are left to the writer, which knows how its format keeps
+		// numbers.
+		if d, ok := field.Interface().(decimal.Decimal); ok {
+			row = append(row, d)
+			continue
+		}
+		switch field.Kind() {
+		case reflect.String:
+			row = append(row, field.String())
+		case reflect.Float32, reflect.Float64:
+			row = append(row, field.Float())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			row = append(row, field.Int())
+		default:
+			row = append(row, fmt.Sprint(field.Interface()))
+		}
+	}
+	return row
+}
+
+func exportName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() || f.Type.Kind() == reflect.Slice {
+		return "", false
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestExportRow(t *testing.T) {
+	type row struct {
+		Name   string           `json:"name"`
+		Price  decimal.Decimal  `json:"price"`
+		Cost   *decimal.Decimal `json:"cost"`
+		Margin *decimal.Decimal `json:"margin"`
+		Lines  []string         `json:"lines"`
+		Count  int              `json:"count"`
+		Hidden string           `json:"-"`
+	}
+	cost := dec("0.1").Add(dec("0.2"))
+
+	got := exportRow(reflect.ValueOf(row{
+		Name:  "Milk",
+		Price: dec("12345678901234567.89"),
+		Cost:  &cost,
+		Lines: []string{"left out"},
+		Count: 3,
+	}))
+	want := []interface{}{"Milk", dec("12345678901234567.89"), cost, "", int64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("row = %#v, want %#v", got, want)
+	}
+
+	header := exportHeader(reflect.TypeOf(row{}))
+	wantHeader := []interface{}{"name", "price", "cost", "margin", "count"}
+	if !reflect.DeepEqual(header, wantHeader) {
+		t.Errorf("header = %#v, want %#v", header, wantHeader)
+	}
+}
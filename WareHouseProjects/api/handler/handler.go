@@ -1,16 +1,18 @@
 package handler
 
 import (
+	"WareHouseProjects/config"
 	"WareHouseProjects/pkg/logger"
 	"WareHouseProjects/storage"
 )
 
 type Handler struct {
+	cfg     config.Config
 	storage storage.StorageI
 
 	log logger.LoggerI
 }
 
-func NewHandler(strg storage.StorageI, loger logger.LoggerI) *Handler {
-	return &Handler{storage: strg, log: loger}
+func NewHandler(cfg config.Config, strg storage.StorageI, loger logger.LoggerI) *Handler {
+	return &Handler{cfg: cfg, storage: strg, log: loger}
 }
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"WareHouseProjects/config"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	postgres "WareHouseProjects/storage/postgress"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// The handler tests that need a database run against the one of
+// TEST_POSTGRES_DSN like the storage tests, and are skipped when it is not
+// set. The database is migrated up but not emptied, so fixtures use unique
+// codes and barcodes; everything a test writes is rolled back when it ends.
+
+var errRollback = errors.New("rolled back")
+
+// testHandler returns a handler and the storage it uses, both on one
+// transaction of the test database.
+func testHandler(t *testing.T) (*Handler, storage.StorageI) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	conn, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parsing TEST_POSTGRES_DSN: %v", err)
+	}
+	cfg := config.Load()
+	cfg.PostgresHost = conn.Host
+	cfg.PostgresPort = int(conn.Port)
+	cfg.PostgresUser = conn.User
+	cfg.PostgresPassword = conn.Password
+	cfg.PostgresDatabase = conn.Database
+	ctx := context.Background()
+
+	m, err := postgres.NewMigrator(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	_, err = m.Up(ctx)
+	m.Close()
+	if err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	strg, err := postgres.NewStorage(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	t.Cleanup(strg.Close)
+
+	// WithTx rolls back when its function fails, so the transaction is held
+	// by a function that fails once the test has ended.
+	var (
+		txc      = make(chan storage.StorageI)
+		done     = make(chan struct{})
+		finished = make(chan struct{})
+		txErr    error
+	)
+	go func() {
+		defer close(finished)
+		txErr = strg.WithTx(ctx, func(tx storage.StorageI) error {
+			txc <- tx
+			<-done
+			return errRollback
+		})
+	}()
+
+	var tx storage.StorageI
+	select {
+	case tx = <-txc:
+	case <-finished:
+		t.Fatalf("beginning: %v", txErr)
+	}
+	t.Cleanup(func() {
+		close(done)
+		<-finished
+	})
+
+	gin.SetMode(gin.TestMode)
+	return NewHandler(cfg, tx, logger.NewLogger("test", logger.LevelError)), tx
+}
+
+// serve calls handle with a JSON request of method to target with body and
+// the path parameters params, and returns what it answered.
+func serve(handle gin.HandlerFunc, method, target string, body io.Reader, params ...gin.Param) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Content-Type", "application/json")
+	return serveRequest(handle, req, params...)
+}
+
+// serveRequest calls handle with req and the path parameters params, and
+// returns what it answered.
+func serveRequest(handle gin.HandlerFunc, req *http.Request, params ...gin.Param) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = params
+	handle(c)
+	return w
+}
+
+// unique returns a code no other test run uses, fit for a branch code.
+func unique() string {
+	return "T" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", ""))[:15]
+}
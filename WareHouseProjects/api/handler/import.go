@@ -0,0 +1,518 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/pkg/sheet"
+	"WareHouseProjects/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxImportSize bounds the size of an uploaded import file.
+const maxImportSize = 10 << 20
+
+var (
+	// errDryRun rolls back the transaction of a dry run.
+	errDryRun = errors.New("dry run")
+	// errImportRows rolls back an import that found invalid rows.
+	errImportRows = errors.New("import has invalid rows")
+)
+
+// ImportProduct godoc
+// @Router       /product/import [POST]
+// @Summary      IMPORT PRODUCTS
+// @Description  imports products from a CSV or XLSX file with the columns name, price, barcode and optionally category.
+// @Description  category is a category id or a path of names such as "Drinks/Soda"; missing categories of a path are created.
+// @Description  Products are upserted by barcode. dry_run reports what a commit would do without saving anything;
+// @Description  commit saves all rows in one transaction and saves nothing if any row is invalid.
+// @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file    true   "CSV or XLSX file"
+// @Param        mode  query     string  false  "import mode" Enums(dry_run, commit) default(dry_run)
+// @Success      200  {object}  response.Response{data=models.ProductImportResult}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) ImportProduct(c *gin.Context) {
+	var req models.ImportRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+
+	table, ok := h.readUpload(c)
+	if !ok || !h.requireColumns(c, table, "name", "price", "barcode") {
+		return
+	}
+
+	rows, rowErrs := parseProductRows(table)
+	result := models.ProductImportResult{Mode: req.Mode, Rows: len(table.Rows), Errors: rowErrs}
+	if req.Mode == models.ImportCommit && len(result.Errors) > 0 {
+		h.importFailed(c, result.Errors)
+		return
+	}
+
+	err := h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		if err := importProducts(c.Request.Context(), strg, rows, &result); err != nil {
+			return err
+		}
+		if req.Mode == models.ImportDryRun {
+			return errDryRun
+		}
+		if len(result.Errors) > 0 {
+			return errImportRows
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errImportRows):
+		h.importFailed(c, result.Errors)
+		return
+	case err != nil && !errors.Is(err, errDryRun):
+		h.handleError(c, "error product import:", err)
+		return
+	}
+
+	sortRowErrors(result.Errors)
+	response.OK(c, http.StatusOK, req.Mode, result)
+}
+
+type productImportRow struct {
+	line     int
+	product  models.CreateProduct
+	category string
+}
+
+// parseProductRows validates every row of table on its own, without the
+// database.
+func parseProductRows(table *sheet.Table) ([]productImportRow, []models.ImportRowError) {
+	var (
+		rows     []productImportRow
+		errs     = make([]models.ImportRowError, 0)
+		barcodes = make(map[string]int)
+	)
+
+	for _, row := range table.Rows {
+		r := productImportRow{
+			line: row.Line,
+			product: models.CreateProduct{
+				Name:    table.Value(row, "name"),
+				Barcode: table.Value(row, "barcode"),
+			},
+			category: table.Value(row, "category"),
+		}
+
+		var rowErrs []models.ImportRowError
+		price, err := parseNumber(table.Value(row, "price"))
+		if err != nil {
+			// 1 keeps the validator from reporting price a second time.
+			price = decimal.NewFromInt(1)
+			rowErrs = append(rowErrs, models.ImportRowError{Line: row.Line, Field: "price", Reason: "must be a number"})
+		}
+		r.product.Price = price
+
+		rowErrs = append(rowErrs, rowErrors(row.Line, binding.Validator.ValidateStruct(&r.product))...)
+		if line, ok := barcodes[r.product.Barcode]; ok && r.product.Barcode != "" {
+			rowErrs = append(rowErrs, models.ImportRowError{Line: row.Line, Field: "barcode", Reason: fmt.Sprintf("duplicates line %d", line)})
+		}
+		barcodes[r.product.Barcode] = row.Line
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		rows = append(rows, r)
+	}
+
+	return rows, errs
+}
+
+// importProducts resolves the categories of rows and upserts the products.
+// Rows whose category id does not exist are reported in result.Errors.
+func importProducts(ctx context.Context, strg storage.StorageI, rows []productImportRow, result *models.ProductImportResult) error {
+	categories := make(map[string]string)
+
+	for _, row := range rows {
+		if row.category != "" {
+			id, ok := categories[strings.ToLower(row.category)]
+			if !ok {
+				var (
+					created int
+					err     error
+				)
+				id, created, err = resolveCategory(ctx, strg, row.category)
+				if errors.Is(err, storage.ErrNotFound) {
+					result.Errors = append(result.Errors, models.ImportRowError{Line: row.line, Field: "category", Reason: "category not found"})
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				categories[strings.ToLower(row.category)] = id
+				result.CategoriesCreated += created
+			}
+			row.product.Category_id = id
+		}
+
+		_, created, err := strg.Product().UpsertProduct(ctx, &row.product)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", row.line, err)
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return nil
+}
+
+// resolveCategory returns the id of category, which is either the id of an
+// existing category or a "/"-separated path of names created as needed.
+func resolveCategory(ctx context.Context, strg storage.StorageI, category string) (string, int, error) {
+	if _, err := uuid.Parse(category); err == nil {
+		resp, err := strg.Category().GetCategory(ctx, &models.CategoryIdRequest{Id: category})
+		if err != nil {
+			return "", 0, err
+		}
+		return resp.ID, 0, nil
+	}
+
+	var path []string
+	for _, name := range strings.Split(category, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			path = append(path, name)
+		}
+	}
+	return strg.Category().EnsureCategoryPath(ctx, path)
+}
+
+// ImportComingTableProduct godoc
+// @Router       /coming_table/{id}/import [POST]
+// @Summary      IMPORT INVOICE
+// @Description  adds the rows of a supplier invoice, a CSV or XLSX file with the columns barcode and count and optionally name, price and category, to a coming table in process.
+// @Description  Rows are matched to products by barcode; a new line is created or the existing line of the barcode is increased.
+// @Description  The invoice price is the purchase cost of the line in the currency of the coming table; rows without it cost what the product cost last time.
+// @Description  Unknown barcodes are reported and skipped, or created as products from name, price and category when create_unknown is set; the invoice price, converted to the base currency, becomes their selling price.
+// @Description  The summary compares the invoice prices with the last purchase costs. dry_run saves nothing.
+// @Tags         coming_table_product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id              path      string  true   "coming table id" format(uuid)
+// @Param        file            formData  file    true   "CSV or XLSX invoice"
+// @Param        mode            query     string  false  "import mode" Enums(dry_run, commit) default(dry_run)
+// @Param        create_unknown  query     bool    false  "create products for unknown barcodes"
+// @Success      200  {object}  response.Response{data=models.ComingTableImportResult}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) ImportComingTableProduct(c *gin.Context) {
+	var req models.ComingTableImportRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	// Unknown barcodes become products, which not every role may create.
+	if req.CreateUnknown && !h.allowed(c, "product", http.MethodPost) {
+		h.forbidden(c, "product", http.MethodPost)
+		return
+	}
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.handleError(c, "error coming table import:", storage.NewError(storage.ErrValidation, "invalid coming table id", err))
+		return
+	}
+
+	table, ok := h.readUpload(c)
+	if !ok || !h.requireColumns(c, table, "barcode", "count") {
+		return
+	}
+
+	rows, rowErrs := parseInvoiceRows(table, id)
+	result := models.ComingTableImportResult{
+		Mode:            req.Mode,
+		Rows:            len(table.Rows),
+		Unknown:         make([]models.UnknownBarcode, 0),
+		PriceMismatches: make([]models.PriceMismatch, 0),
+		Errors:          rowErrs,
+	}
+	if req.Mode == models.ImportCommit && len(result.Errors) > 0 {
+		h.importFailed(c, result.Errors)
+		return
+	}
+
+	err := h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		if _, err := strg.Coming_Table().GetStatus(c.Request.Context(), &models.ComingTableIdRequest{Id: id}); err != nil {
+			return fmt.Errorf("getting coming table status: %w", err)
+		}
+		if err := h.importInvoice(c.Request.Context(), strg, rows, req.CreateUnknown, &result); err != nil {
+			return err
+		}
+		if req.Mode == models.ImportDryRun {
+			return errDryRun
+		}
+		if len(result.Errors) > 0 {
+			return errImportRows
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errImportRows):
+		h.importFailed(c, result.Errors)
+		return
+	case err != nil && !errors.Is(err, errDryRun):
+		h.handleError(c, "error coming table import:", err)
+		return
+	}
+
+	sortRowErrors(result.Errors)
+	response.OK(c, http.StatusOK, req.Mode, result)
+}
+
+type invoiceRow struct {
+	line     int
+	product  productImportRow
+	arrival  models.CreateComingTableProduct
+	hasPrice bool
+}
+
+// parseInvoiceRows validates every row of an invoice for the coming table
+// with id on its own, without the database.
+func parseInvoiceRows(table *sheet.Table, id string) ([]invoiceRow, []models.ImportRowError) {
+	var (
+		rows []invoiceRow
+		errs = make([]models.ImportRowError, 0)
+	)
+
+	for _, row := range table.Rows {
+		r := invoiceRow{
+			line: row.Line,
+			product: productImportRow{
+				line: row.Line,
+				product: models.CreateProduct{
+					Name:    table.Value(row, "name"),
+					Barcode: table.Value(row, "barcode"),
+				},
+				category: table.Value(row, "category"),
+			},
+			arrival: models.CreateComingTableProduct{
+				Barcode:         table.Value(row, "barcode"),
+				Coming_Table_id: id,
+			},
+		}
+
+		var rowErrs []models.ImportRowError
+		count, err := parseNumber(table.Value(row, "count"))
+		if err != nil {
+			// 1 keeps the validator from reporting count a second time.
+			count = decimal.NewFromInt(1)
+			rowErrs = append(rowErrs, models.ImportRowError{Line: row.Line, Field: "count", Reason: "must be a number"})
+		}
+		r.arrival.Count = count
+
+		if price := table.Value(row, "price"); price != "" {
+			r.product.product.Price, err = parseNumber(price)
+			if err != nil {
+				rowErrs = append(rowErrs, models.ImportRowError{Line: row.Line, Field: "price", Reason: "must be a number"})
+			} else if !r.product.product.Price.IsPositive() {
+				rowErrs = append(rowErrs, models.ImportRowError{Line: row.Line, Field: "price", Reason: "must be greater than 0"})
+			} else {
+				r.arrival.Cost = r.product.product.Price
+			}
+			r.hasPrice = true
+		}
+
+		rowErrs = append(rowErrs, rowErrors(row.Line, binding.Validator.ValidateStruct(&r.arrival))...)
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		rows = append(rows, r)
+	}
+
+	return rows, errs
+}
+
+// importInvoice adds rows to their coming table and fills the reconciliation
+// summary. Unknown barcodes are skipped or, with createUnknown, created as
+// products first.
+func (h *Handler) importInvoice(ctx context.Context, strg storage.StorageI, rows []invoiceRow, createUnknown bool, result *models.ComingTableImportResult) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	doc, err := strg.Coming_Table().GetComingTable(ctx, &models.ComingTableIdRequest{Id: rows[0].arrival.Coming_Table_id})
+	if err != nil {
+		return fmt.Errorf("getting coming table: %w", err)
+	}
+
+	for _, row := range rows {
+		barcode := models.CheckBarcodeComingTable{Barcode: row.arrival.Barcode}
+		known, err := strg.Product().GetProductByBarcode(ctx, &barcode)
+		if errors.Is(err, storage.ErrNotFound) {
+			if !createUnknown {
+				result.Unknown = append(result.Unknown, models.UnknownBarcode{Line: row.line, Barcode: row.arrival.Barcode, Name: row.product.product.Name})
+				continue
+			}
+
+			// The invoice price becomes the selling price, in the base currency.
+			created := row.product
+			created.product.Price = created.product.Price.Mul(doc.ExchangeRate)
+			product := models.ProductImportResult{Errors: rowErrors(row.line, binding.Validator.ValidateStruct(&created.product))}
+			if len(product.Errors) == 0 {
+				if err := importProducts(ctx, strg, []productImportRow{created}, &product); err != nil {
+					return err
+				}
+			}
+			result.ProductsCreated += product.Created
+			if len(product.Errors) > 0 {
+				result.Errors = append(result.Errors, product.Errors...)
+				continue
+			}
+		} else if err != nil {
+			return fmt.Errorf("line %d: %w", row.line, err)
+		}
+
+		line := row.arrival
+		_, created, err := h.addComingTableProduct(ctx, strg, &line)
+		var storageErr *storage.Error
+		if errors.Is(err, storage.ErrValidation) && errors.As(err, &storageErr) {
+			result.Errors = append(result.Errors, models.ImportRowError{Line: row.line, Field: storageErr.Field, Reason: storageErr.Message})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", row.line, err)
+		}
+		if created {
+			result.LinesCreated++
+		} else {
+			result.LinesUpdated++
+		}
+
+		result.TotalCount = result.TotalCount.Add(line.Count)
+		result.TotalPrice = result.TotalPrice.Add(line.TotalPrice)
+		if row.hasPrice {
+			result.InvoiceTotal = result.InvoiceTotal.Add(row.product.product.Price.Mul(line.Count))
+			if known != nil && known.Cost != nil && !known.Cost.Equal(line.Cost) {
+				result.PriceMismatches = append(result.PriceMismatches, models.PriceMismatch{
+					Line:         row.line,
+					Barcode:      line.Barcode,
+					InvoicePrice: line.Cost,
+					LastCost:     *known.Cost,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// readUpload reads the CSV or XLSX file uploaded in the "file" form field.
+// On failure it writes the error response and returns false.
+func (h *Handler) readUpload(c *gin.Context) (*sheet.Table, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		h.badRequest(c, "file is required and must be at most 10 MB", err)
+		return nil, false
+	}
+	format, err := sheet.FormatOf(header.Filename)
+	if err != nil {
+		h.badRequest(c, err.Error(), err)
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.badRequest(c, "cannot open file", err)
+		return nil, false
+	}
+	defer file.Close()
+
+	table, err := sheet.Read(format, file)
+	if err != nil {
+		h.badRequest(c, "cannot read file", err)
+		return nil, false
+	}
+	return table, true
+}
+
+// requireColumns answers with a 422 listing the columns table lacks.
+func (h *Handler) requireColumns(c *gin.Context, table *sheet.Table, columns ...string) bool {
+	var fields []response.FieldError
+	for _, column := range columns {
+		if !table.Has(column) {
+			fields = append(fields, response.FieldError{Field: column, Reason: "column is missing"})
+		}
+	}
+	if len(fields) == 0 {
+		return true
+	}
+
+	response.Error(c, http.StatusUnprocessableEntity, CodeValidation, "file is missing required columns", fields...)
+	return false
+}
+
+// importFailed answers a commit that found invalid rows with a 422 whose
+// fields name the line and column of each problem.
+func (h *Handler) importFailed(c *gin.Context, errs []models.ImportRowError) {
+	sortRowErrors(errs)
+
+	fields := make([]response.FieldError, 0, len(errs))
+	for _, e := range errs {
+		field := fmt.Sprintf("rows[%d]", e.Line)
+		if e.Field != "" {
+			field += "." + e.Field
+		}
+		fields = append(fields, response.FieldError{Field: field, Reason: e.Reason})
+	}
+
+	h.log.Warn("import rejected", logger.Int("invalid_rows", len(errs)))
+	response.Error(c, http.StatusUnprocessableEntity, CodeValidation, "import has invalid rows, nothing was saved", fields...)
+}
+
+// rowErrors converts the validation errors of the row at line.
+func rowErrors(line int, err error) []models.ImportRowError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	errs := make([]models.ImportRowError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		errs = append(errs, models.ImportRowError{Line: line, Field: fe.Field(), Reason: reason(fe)})
+	}
+	return errs
+}
+
+func sortRowErrors(errs []models.ImportRowError) {
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+}
+
+// parseNumber parses a number written with either a decimal point or a
+// decimal comma, as spreadsheets export them.
+func parseNumber(s string) (decimal.Decimal, error) {
+	return decimal.NewFromString(strings.Replace(strings.ReplaceAll(s, " ", ""), ",", ".", 1))
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestImportComingTableProduct(t *testing.T) {
+	h, strg := testHandler(t)
+	ctx := context.Background()
+
+	unitID, err := strg.Unit().CreateUnit(ctx, &models.CreateUnit{Name: "Piece", ShortName: unique(), Precision: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	milk, bread := uuid.NewString(), uuid.NewString()
+	for _, p := range []models.CreateProduct{
+		{Name: "Milk", Price: dec("20000"), Barcode: milk},
+		{Name: "Bread", Price: dec("8000"), Barcode: bread, Unit_id: unitID},
+	} {
+		p := p
+		if _, err := strg.Product().CreateProduct(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Milk last cost 12600 in the base currency, 1 USD.
+	previous := newComingTable(t, strg)
+	if _, err := strg.Coming_TableProduct().CreateComingTableProduct(ctx, &models.CreateComingTableProduct{Coming_Table_id: previous, Name: "Milk", Barcode: milk, Price: dec("20000"), Count: dec("1"), Cost: dec("12600"), DocCost: dec("1"), TotalPrice: dec("12600"), DocTotalPrice: dec("1")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strg.Coming_Table().UpdateStatus(ctx, &models.ComingTableIdRequest{Id: previous}); err != nil {
+		t.Fatal(err)
+	}
+
+	id := newComingTable(t, strg)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "invoice.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(file, "barcode,count,price\n%s,2,1.5\n%s,1.5,0.5\n", milk, bread)
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/coming_table/"+id+"/import?mode=dry_run", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	w := serveRequest(h.ImportComingTableProduct, req, gin.Param{Key: "id", Value: id})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Data models.ComingTableImportResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	result := resp.Data
+
+	if result.LinesCreated != 1 || !result.TotalPrice.Equal(dec("37800")) {
+		t.Errorf("%d lines created worth %s, want 1 worth 37800", result.LinesCreated, result.TotalPrice)
+	}
+	if len(result.PriceMismatches) != 1 {
+		t.Fatalf("price mismatches = %+v, want milk", result.PriceMismatches)
+	}
+	if m := result.PriceMismatches[0]; !m.InvoicePrice.Equal(dec("18900")) || !m.LastCost.Equal(dec("12600")) {
+		t.Errorf("milk invoiced at %s, last cost %s; want 18900 and 12600, both in the base currency", m.InvoicePrice, m.LastCost)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 || result.Errors[0].Field != "count" {
+		t.Errorf("errors = %+v, want the count of bread on line 3", result.Errors)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/pkg/logger"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestID tags the request with the id sent by the client in X-Request-ID,
+// or a fresh one, and echoes it back in the header and response envelope.
+func (h *Handler) RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(response.RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+
+		c.Set(response.RequestIDKey, id)
+		c.Header(response.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// DBTimeout puts the configured deadline on the request context, so every
+// query started from c.Request.Context() is cancelled once it expires or the
+// client goes away. Exports get the longer export deadline: their headers are
+// sent with the first page, so running out of time later could only cut the
+// file short.
+func (h *Handler) DBTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := h.cfg.DBTimeout
+		if c.Query("export") != "" {
+			timeout = h.cfg.ExportTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 envelope.
+func (h *Handler) Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err interface{}) {
+		h.log.Error("panic recovered", logger.Any("panic", err))
+		response.Error(c, http.StatusInternalServerError, CodeInternal, http.StatusText(http.StatusInternalServerError))
+	})
+}
+
+// NoRoute answers unknown routes with a 404 envelope.
+func (h *Handler) NoRoute(c *gin.Context) {
+	response.Error(c, http.StatusNotFound, CodeNotFound, "route not found")
+}
+
+// Health answers 200 while the server is up. It needs no authentication.
+func (h *Handler) Health(c *gin.Context) {
+	response.OK(c, http.StatusOK, "ok", nil)
+}
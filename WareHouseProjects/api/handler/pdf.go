@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/fonts"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+)
+
+// ComingTablePDF godoc
+// @Router       /coming_table/{id}/pdf [GET]
+// @Summary      PRINT ComingTable
+// @Description  renders a coming table as a printable PDF: branch header, lines, totals by tax rate and a signature block
+// @Tags         coming_table
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Produce      application/pdf
+// @Param        id   path      string  true  "ComingTable ID" format(uuid)
+// @Success      200  {file}    file
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) ComingTablePDF(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	doc, err := h.storage.Coming_Table().GetComingTable(ctx, &models.ComingTableIdRequest{Id: c.Param("id")})
+	if err != nil {
+		h.handleError(c, "error ComingTable pdf:", err)
+		return
+	}
+	branch, err := h.storage.Branch().GetBranch(ctx, &models.BranchIdRequest{Id: doc.BranchID})
+	if err != nil {
+		h.handleError(c, "error ComingTable pdf:", err)
+		return
+	}
+	lines, err := h.storage.Coming_TableProduct().GetComingTableById(ctx, &models.ComingTableProductIdRequest{Id: doc.ID})
+	if err != nil {
+		h.handleError(c, "error ComingTable pdf:", err)
+		return
+	}
+	doc.Taxes, err = h.storage.Coming_TableProduct().GetComingTableTaxes(ctx, &models.ComingTableIdRequest{Id: doc.ID})
+	if err != nil {
+		h.handleError(c, "error ComingTable pdf:", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := renderComingTable(&buf, doc, branch, lines); err != nil {
+		h.handleError(c, "error ComingTable pdf:", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="coming-table-%s.pdf"`, doc.ComingID))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// Column widths of the lines table in mm; together they fill the 180 mm
+// between the margins of an A4 page.
+var pdfColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"#", 10, "R"},
+	{"Barcode", 35, "L"},
+	{"Name", 65, "L"},
+	{"Count", 20, "R"},
+	{"Cost", 25, "R"},
+	{"Total", 25, "R"},
+}
+
+const (
+	pdfMargin     = 15.0
+	pdfLineHeight = 6.0
+)
+
+func renderComingTable(w io.Writer, doc *models.ComingTable, branch *models.Branch, lines []models.ComingTableProduct) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("DejaVu", "", fonts.Regular)
+	pdf.AddUTF8FontFromBytes("DejaVu", "B", fonts.Bold)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	// Rows are kept whole by pdfEnsureSpace instead.
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 3)
+		pdf.SetFont("DejaVu", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s · page %d/{nb}", doc.ComingID, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Branch header.
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(0, 7, branch.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 10)
+	for _, text := range []string{branch.Address, branch.Phone} {
+		if text != "" {
+			pdf.CellFormat(0, 5, text, "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("DejaVu", "B", 13)
+	pdf.CellFormat(0, 8, "Goods receipt No. "+doc.ComingID, "", 1, "C", false, 0, "")
+	pdf.SetFont("DejaVu", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Date: %s    Status: %s", doc.DateTime, doc.Status), "", 1, "C", false, 0, "")
+	// Costs are printed in the base currency; doc.Currency is empty for it.
+	if doc.Currency != "" {
+		pdf.CellFormat(0, 6, fmt.Sprintf("Invoiced in %s at %s", doc.Currency, doc.ExchangeRate), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Lines.
+	pdfTableHeader(pdf)
+	var count, total decimal.Decimal
+	for i, line := range lines {
+		pdfTableRow(pdf, []string{
+			strconv.Itoa(i + 1),
+			line.Barcode,
+			line.Name,
+			strings.TrimSpace(formatCount(line.Count) + " " + line.Unit),
+			formatMoney(line.Cost),
+			formatMoney(line.TotalPrice),
+		})
+		count = count.Add(line.Count)
+		total = total.Add(line.TotalPrice)
+	}
+
+	pdfEnsureSpace(pdf, pdfLineHeight)
+	pdf.SetFont("DejaVu", "B", 10)
+	label := pdfColumns[0].width + pdfColumns[1].width + pdfColumns[2].width
+	pdf.CellFormat(label, pdfLineHeight, fmt.Sprintf("Total, %d lines", len(lines)), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(pdfColumns[3].width, pdfLineHeight, formatCount(count), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(pdfColumns[4].width, pdfLineHeight, "", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(pdfColumns[5].width, pdfLineHeight, formatMoney(total), "1", 1, "R", false, 0, "")
+
+	// Totals by tax rate, under the right-hand columns.
+	if len(doc.Taxes) > 0 {
+		pdfTaxes(pdf, doc)
+	}
+
+	// Signature block, kept on one page.
+	pdfEnsureSpace(pdf, 50)
+	pdf.Ln(16)
+	pdf.SetFont("DejaVu", "", 10)
+	half := (210 - 2*pdfMargin) / 2
+	pdf.CellFormat(half, 6, "Delivered by:", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, 6, "Received by:", "", 1, "L", false, 0, "")
+	pdf.Ln(8)
+	pdf.CellFormat(half, 6, "______________ / ______________", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, 6, "______________ / ______________", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 7)
+	pdf.CellFormat(half, 4, "signature                     full name", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, 4, "signature                     full name", "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+	pdf.SetFont("DejaVu", "", 10)
+	pdf.CellFormat(0, 6, "Date: ____ . ____ . ________", "", 1, "L", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+// pdfTaxes draws the net, tax and gross totals of doc by tax rate and
+// overall, right-aligned under the lines.
+func pdfTaxes(pdf *fpdf.Fpdf, doc *models.ComingTable) {
+	const width = 25.0
+	left := 210 - pdfMargin - 4*width
+
+	pdfEnsureSpace(pdf, float64(len(doc.Taxes)+3)*pdfLineHeight)
+	pdf.Ln(4)
+	pdf.SetFont("DejaVu", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.SetX(left)
+	for _, title := range []string{"Tax rate", "Net", "Tax", "Gross"} {
+		pdf.CellFormat(width, pdfLineHeight, title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("DejaVu", "", 9)
+	for _, tax := range doc.Taxes {
+		pdf.SetX(left)
+		pdf.CellFormat(width, pdfLineHeight, tax.TaxRate.String()+"%", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(width, pdfLineHeight, formatMoney(tax.NetAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(width, pdfLineHeight, formatMoney(tax.TaxAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(width, pdfLineHeight, formatMoney(tax.GrossAmount), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("DejaVu", "B", 9)
+	pdf.SetX(left)
+	pdf.CellFormat(width, pdfLineHeight, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(width, pdfLineHeight, formatMoney(doc.NetAmount), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(width, pdfLineHeight, formatMoney(doc.TaxAmount), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(width, pdfLineHeight, formatMoney(doc.GrossAmount), "1", 1, "R", false, 0, "")
+}
+
+func pdfTableHeader(pdf *fpdf.Fpdf) {
+	pdf.SetFont("DejaVu", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range pdfColumns {
+		pdf.CellFormat(col.width, pdfLineHeight, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("DejaVu", "", 9)
+}
+
+// pdfTableRow draws one row, wrapping long names. A row that does not fit
+// goes to the next page under a repeated header.
+func pdfTableRow(pdf *fpdf.Fpdf, cells []string) {
+	height := pdfLineHeight
+	wrapped := make([][]string, len(cells))
+	for i, text := range cells {
+		wrapped[i] = pdf.SplitText(text, pdfColumns[i].width-2)
+		if h := float64(len(wrapped[i])) * pdfLineHeight; h > height {
+			height = h
+		}
+	}
+
+	if pdfEnsureSpace(pdf, height) {
+		pdfTableHeader(pdf)
+	}
+
+	x, y := pdf.GetXY()
+	for i, col := range pdfColumns {
+		pdf.Rect(x, y, col.width, height, "D")
+		for j, text := range wrapped[i] {
+			pdf.SetXY(x, y+float64(j)*pdfLineHeight)
+			pdf.CellFormat(col.width, pdfLineHeight, text, "", 0, col.align, false, 0, "")
+		}
+		x += col.width
+	}
+	pdf.SetXY(pdfMargin, y+height)
+}
+
+// pdfEnsureSpace starts a new page unless height mm fit above the footer,
+// and reports whether it did.
+func pdfEnsureSpace(pdf *fpdf.Fpdf, height float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+height <= pageHeight-pdfMargin-5 {
+		return false
+	}
+	pdf.AddPage()
+	return true
+}
+
+func formatMoney(v decimal.Decimal) string {
+	return v.StringFixed(2)
+}
+
+func formatCount(v decimal.Decimal) string {
+	return v.String()
+}
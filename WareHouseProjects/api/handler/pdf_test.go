@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"WareHouseProjects/models"
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestComingTablePDF(t *testing.T) {
+	h, strg := testHandler(t)
+	id := newComingTable(t, strg)
+	addLines(t, strg, id)
+
+	w := serve(h.ComingTablePDF, http.MethodGet, "/coming_table/"+id+"/pdf", nil, gin.Param{Key: "id", Value: id})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("content type = %s, want application/pdf", ct)
+	}
+	if body := w.Body.Bytes(); !bytes.HasPrefix(body, []byte("%PDF-")) || !bytes.Contains(body, []byte("%%EOF")) {
+		t.Errorf("body is not a whole PDF: %.40q", body)
+	}
+}
+
+func TestRenderComingTable(t *testing.T) {
+	doc := &models.ComingTable{
+		ComingID:      "NRT-IN-2026-000001",
+		DateTime:      "2026-03-05 14:30:00",
+		Status:        models.InProcess,
+		Currency:      "USD",
+		ExchangeRate:  dec("12600"),
+		TotalCount:    dec("2.5"),
+		TotalPrice:    dec("63000"),
+		DocTotalPrice: dec("5"),
+		NetAmount:     dec("63000"),
+		TaxAmount:     dec("4536"),
+		GrossAmount:   dec("67536"),
+		Taxes: []models.ComingTableTax{
+			{TaxRate: dec("0"), NetAmount: dec("25200"), GrossAmount: dec("25200")},
+			{TaxRate: dec("12"), NetAmount: dec("37800"), TaxAmount: dec("4536"), GrossAmount: dec("42336")},
+		},
+	}
+	lines := []models.ComingTableProduct{
+		{Barcode: "1001", Name: "Молоко", Count: dec("2"), Cost: dec("18900"), DocCost: dec("1.5"), TotalPrice: dec("37800"), DocTotalPrice: dec("3"), TaxRate: dec("12")},
+		{Barcode: "1003", Name: "Bread", Count: dec("0.5"), Cost: dec("50400"), DocCost: dec("4"), TotalPrice: dec("25200"), DocTotalPrice: dec("2")},
+	}
+
+	var buf bytes.Buffer
+	if err := renderComingTable(&buf, doc, &models.Branch{Name: "North", Code: "NRT", Address: "1 Main St"}, lines); err != nil {
+		t.Fatal(err)
+	}
+	if body := buf.Bytes(); !bytes.HasPrefix(body, []byte("%PDF-")) || !bytes.Contains(body, []byte("%%EOF")) {
+		t.Errorf("body is not a whole PDF: %.40q", body)
+	}
+}
@@ -1,10 +1,9 @@
 package handler
 
 import (
+	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/logger"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,29 +13,31 @@ import (
 // @Summary      CREATE PRODUCT
 // @Description adds product data to db based on given info in body
 // @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        data  body      models.CreateProduct  true  "product data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateProduct(c *gin.Context) {
 	var product models.CreateProduct
-	err := c.ShouldBind(&product)
-	if err != nil {
-		h.log.Error("error while binding product:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid body")
+	if !h.bind(c, &product) {
 		return
 	}
 
-	resp, err := h.storage.Product().CreateProduct(&product)
+	resp, err := h.storage.Product().CreateProduct(c.Request.Context(), &product)
 	if err != nil {
-		h.log.Error("error product create:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error product create:", err)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "success", "resp": resp})
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
 }
 
 // GetProduct godoc
@@ -44,24 +45,27 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @Summary      GET BY ID
 // @Description  gets product by ID
 // @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Product ID" format(uuid)
-// @Success      200  {object}  models.Product
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.Product}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Product().GetProduct(&models.ProductIdRequest{Id: id})
+	resp, err := h.storage.Product().GetProduct(c.Request.Context(), &models.ProductIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error get product:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error get product:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetALlProducts godoc
@@ -69,43 +73,118 @@ func (h *Handler) GetProduct(c *gin.Context) {
 // @Summary      LIST PRODUCT
 // @Description  gets all product based on limit, page and search by name
 // @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param  		 limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
-// @Param   	 name          query     string     false  "name"
-// @Param   	 barcode       query     string     false  "barcode"
-// @Success      200  {object}  models.GetAllProductRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, price, barcode, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        name            query     string    false  "search by name"
+// @Param        barcode         query     string    false  "exact barcode"
+// @Param        category_id     query     string    false  "category id" format(uuid)
+// @Param        unit_id         query     string    false  "unit id" format(uuid)
+// @Param        price_from      query     number    false  "minimum price"
+// @Param        price_to        query     number    false  "maximum price"
+// @Success      200  {object}  response.Response{data=[]models.Product,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllProduct(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	var req models.GetAllProductRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "products", &req.ListRequest, func() ([]models.Product, string, error) {
+			resp, err := h.storage.Product().GetAllProduct(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Products, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.Product().GetAllProduct(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error get page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+		h.handleError(c, "error Product GetAllProduct:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.Products, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// GetProductByBarcode godoc
+// @Router       /product/barcode/{barcode} [GET]
+// @Summary      GET BY BARCODE
+// @Description  gets a product by barcode at the price of a branch, which is the product price unless the branch overrides it
+// @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        barcode     path      string  true   "barcode"
+// @Param        branch_id   query     string  false  "branch id" format(uuid)
+// @Success      200  {object}  response.Response{data=models.RespBarcodeProduct}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetProductByBarcode(c *gin.Context) {
+	var req models.CheckBarcodeComingTable
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	req.Barcode = c.Param("barcode")
+
+	resp, err := h.storage.Product().GetProductByBarcode(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error get limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+		h.handleError(c, "error get product by barcode:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// SearchProduct godoc
+// @Router       /product/search [GET]
+// @Summary      SEARCH PRODUCT
+// @Description  searches products by name, barcode and category name, tolerating misspellings; best matches first
+// @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        q               query     string    true   "search text"  maxlength(100)
+// @Param        category_id     query     string    false  "category id" format(uuid)
+// @Param  		 limit           query     int       false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"  minimum(1)
+// @Success      200  {object}  response.Response{data=[]models.ProductSearchResult}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) SearchProduct(c *gin.Context) {
+	var req models.SearchProductRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
+	h.pageLimit(&req.Limit)
 
-	resp, err := h.storage.Product().GetAllProduct(&models.GetAllProductRequest{
-		Page:    page,
-		Limit:   limit,
-		Barcode: c.Query("search"),
-		Name:    c.Query("search"),
-	})
+	resp, err := h.storage.Product().SearchProduct(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error Product GetAllProduct:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error Product SearchProduct:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // UpdateProduct godoc
@@ -113,33 +192,35 @@ func (h *Handler) GetAllProduct(c *gin.Context) {
 // @Summary      UPDATE PRODUCT
 // @Description  UPDATES PRODUCT BASED ON GIVEN DATA AND ID
 // @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of product" format(uuid)
 // @Param        data  body      models.UpdateProduct  true  "product data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateProduct(c *gin.Context) {
 	var product models.UpdateProduct
 
-	err := c.ShouldBind(&product)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(c, &product) {
 		return
 	}
 
 	product.ID = c.Param("id")
-	resp, err := h.storage.Product().UpdateProduct(&product)
+	resp, err := h.storage.Product().UpdateProduct(c.Request.Context(), &product)
 	if err != nil {
-		h.log.Error("error product update:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(c, "error product update:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteProduct godoc
@@ -147,22 +228,27 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 // @Summary      DELETE PRODUCT BY ID
 // @Description  deletes product by id
 // @Tags         product
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of product" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Product().DeleteProduct(&models.ProductIdRequest{Id: id})
+	resp, err := h.storage.Product().DeleteProduct(c.Request.Context(), &models.ProductIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting Product:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Product"})
+		h.handleError(c, "error deleting Product:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
 }
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateProductPrice godoc
+// @Router       /product/{id}/price [POST]
+// @Summary      CHANGE PRODUCT PRICE
+// @Description  changes the price of a product at effective_at, or right away when it is empty or past; the change is kept in the price history
+// @Tags         product_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "id of product" format(uuid)
+// @Param        data  body      models.CreateProductPrice  true  "price change"
+// @Success      201  {object}  response.Response{data=models.ProductPrice}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateProductPrice(c *gin.Context) {
+	var req models.CreateProductPrice
+	if !h.bind(c, &req) {
+		return
+	}
+	req.ProductID = c.Param("id")
+
+	ctx := c.Request.Context()
+	id, err := h.storage.ProductPrice().CreateProductPrice(ctx, &req)
+	if err != nil {
+		h.handleError(c, "error product price create:", err)
+		return
+	}
+	// A change that is already due is applied now rather than on the next
+	// scheduler tick. Should that fail, the scheduler applies it later.
+	if _, err := h.storage.ProductPrice().ApplyDuePrices(ctx); err != nil {
+		h.log.Error("error applying due prices:", logger.Error(err))
+	}
+
+	resp, err := h.storage.ProductPrice().GetProductPrice(ctx, &models.ProductPriceIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error product price create:", err)
+		return
+	}
+
+	response.OK(c, http.StatusCreated, "created", resp)
+}
+
+// GetAllProductPrice godoc
+// @Router       /product_price [GET]
+// @Summary      LIST PRODUCT PRICE CHANGES
+// @Description  gets the applied and scheduled price changes of products
+// @Tags         product_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: price, effective_at, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        product_id      query     string    false  "product id" format(uuid)
+// @Param        status          query     string    false  "status" Enums(scheduled, applied)
+// @Param        date_from       query     string    false  "effective from, 2006-01-02"
+// @Param        date_to         query     string    false  "effective until, inclusive, 2006-01-02"
+// @Success      200  {object}  response.Response{data=[]models.ProductPrice,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllProductPrice(c *gin.Context) {
+	var req models.GetAllProductPriceRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "product-prices", &req.ListRequest, func() ([]models.ProductPrice, string, error) {
+			resp, err := h.storage.ProductPrice().GetAllProductPrice(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.ProductPrices, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.ProductPrice().GetAllProductPrice(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error ProductPrice GetAllProductPrice:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.ProductPrices, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// DeleteProductPrice godoc
+// @Router       /product_price/{id} [DELETE]
+// @Summary      CANCEL SCHEDULED PRICE CHANGE
+// @Description  deletes a price change that has not been applied yet
+// @Tags         product_price
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of price change" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteProductPrice(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.ProductPrice().DeleteProductPrice(c.Request.Context(), &models.ProductPriceIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting ProductPrice:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
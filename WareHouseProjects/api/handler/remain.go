@@ -1,10 +1,11 @@
 package handler
 
 import (
+	"WareHouseProjects/api/handler/response"
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,77 +15,69 @@ import (
 // @Summary      CREATE Remain
 // @Description adds Remain data to db based on given id
 // @Tags         remain
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        coming_table_id path string true "Coming Table ID"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=[]string}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) CreateRemain(c *gin.Context) {
 	comingTableID := c.Param("coming_table_id")
-	var remain models.CreateRemain
+	ids := make([]string, 0)
 
-	// Check status
-	comingTableIDRequest := models.ComingTableIdRequest{Id: comingTableID}
-	branchID, err := h.storage.Coming_Table().GetStatus(&comingTableIDRequest)
-	if err != nil {
-		h.log.Error("error while getting coming table status", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
-		return
-	}
-	remain.Branch_id = branchID
-
-	comingIDRequest := models.ComingTableProductIdRequest{Id: comingTableID}
-	comingTableData, err := h.storage.Coming_TableProduct().GetComingTableById(&comingIDRequest)
-	if err != nil {
-		h.log.Error("error while getting coming table data details:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Not Found Coming_table_data with that coming_id"})
-		return
-	}
-
-	remain.Barcode = comingTableData.Barcode
-	remain.Category_id = comingTableData.Category_id
-	remain.Price = comingTableData.Price
-	remain.Count = comingTableData.Count
-	remain.TotalPrice = comingTableData.TotalPrice
-	remain.Name = comingTableData.Name
+	err := h.storage.WithTx(c.Request.Context(), func(strg storage.StorageI) error {
+		// Check status
+		comingTableIDRequest := models.ComingTableIdRequest{Id: comingTableID}
+		branchID, err := strg.Coming_Table().GetStatus(c.Request.Context(), &comingTableIDRequest)
+		if err != nil {
+			return fmt.Errorf("getting coming table status: %w", err)
+		}
 
-	checkRemainRequest := models.CheckRemain{Branch_id: branchID, Barcode: remain.Barcode}
-	id, err := h.storage.Remaining().CheckRemain(&checkRemainRequest)
-	if err != nil {
-		h.log.Info("remaining not found, creating new remaining", logger.Error(err))
-		resp, err := h.storage.Remaining().CreateRemain(&remain)
+		comingIDRequest := models.ComingTableProductIdRequest{Id: comingTableID}
+		lines, err := strg.Coming_TableProduct().GetComingTableById(c.Request.Context(), &comingIDRequest)
 		if err != nil {
-			h.log.Error("error creating remaining:", logger.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
-			return
+			return fmt.Errorf("getting coming table products: %w", err)
+		}
+		if len(lines) == 0 {
+			return storage.NewError(storage.ErrInvalidState, "coming table has no products", nil)
 		}
-		c.JSON(http.StatusCreated, gin.H{"code": http.StatusCreated, "message": "added new remaining", "resp": resp})
-		h.storage.Coming_Table().UpdateStatus(&comingTableIDRequest)
-		return
-	}
 
-	updatingData := models.UpdateRemain{
-		ID:          id,
-		Branch_id:   remain.Branch_id,
-		Category_id: remain.Category_id,
-		Name:        remain.Name,
-		Price:       remain.Price,
-		Barcode:     remain.Barcode,
-		Count:       remain.Count,
-		TotalPrice:  remain.TotalPrice,
-	}
-	r, err := h.storage.Remaining().UpdateIdAviable(&updatingData)
+		for _, line := range lines {
+			remain := models.CreateRemain{
+				Branch_id:   branchID,
+				Category_id: line.Category_id,
+				Name:        line.Name,
+				Price:       line.Price,
+				Cost:        line.Cost,
+				Barcode:     line.Barcode,
+				Count:       line.Count,
+				TotalPrice:  line.TotalPrice,
+			}
+
+			id, err := strg.Remaining().AddRemain(c.Request.Context(), &remain)
+			if err != nil {
+				return fmt.Errorf("adding remaining: %w", err)
+			}
+			ids = append(ids, id)
+		}
+
+		// If everything is ok, change status to finished
+		_, err = strg.Coming_Table().UpdateStatus(c.Request.Context(), &comingTableIDRequest)
+		return err
+	})
 	if err != nil {
-		h.log.Info("error updating remaining:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		h.handleError(c, "error while doing income:", err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "updated existing remaining table", "resp": r})
 
-	// If everything is ok, change status to finished
-	h.storage.Coming_Table().UpdateStatus(&comingTableIDRequest)
+	response.OK(c, http.StatusOK, "income applied to remaining", ids)
 }
 
 // GetRemain godoc
@@ -92,24 +85,27 @@ func (h *Handler) CreateRemain(c *gin.Context) {
 // @Summary      GET BY ID
 // @Description  gets Remain by ID
 // @Tags         remain
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Remain ID" format(uuid)
-// @Success      200  {object}  models.Remain
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=models.Remain}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetRemain(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Remaining().GetRemain(&models.RemainIdRequest{Id: id})
+	resp, err := h.storage.Remaining().GetRemain(c.Request.Context(), &models.RemainIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error get Remain:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, err.Error())
+		h.handleError(c, "error get Remain:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.OK(c, http.StatusOK, "success", resp)
 }
 
 // GetALlRemains godoc
@@ -117,45 +113,53 @@ func (h *Handler) GetRemain(c *gin.Context) {
 // @Summary      LIST Remain
 // @Description  gets all Remain based on limit, page and search by name
 // @Tags         remain
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param  		 limit         query     int        false  "limit"          minimum(1)     default(10)
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
 // @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
-// @Param   	 branch_id        query     string     false  "branch_id"
-// @Param   	 category_id        query     string     false  "category_id"
-// @Param   	 barcode        query     string     false  "barcode"
-// @Success      200  {object}  models.GetAllRemainRequest
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, price, cost, barcode, count, total_price, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        branch_id       query     string    false  "branch id" format(uuid)
+// @Param        category_id     query     string    false  "category id" format(uuid)
+// @Param        product_id      query     string    false  "product id" format(uuid)
+// @Param        barcode         query     string    false  "exact barcode"
+// @Param        name            query     string    false  "search by name"
+// @Param        price_from      query     number    false  "minimum price"
+// @Param        price_to        query     number    false  "maximum price"
+// @Success      200  {object}  response.Response{data=[]models.Remain,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) GetAllRemain(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		h.log.Error("error get page:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	var req models.GetAllRemainRequest
+	if !h.bindQuery(c, &req) {
 		return
 	}
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		h.log.Error("error get limit:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, "invalid page param")
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "remaining", &req.ListRequest, func() ([]models.Remain, string, error) {
+			resp, err := h.storage.Remaining().GetAllRemain(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Remainings, resp.NextCursor, nil
+		})
 		return
 	}
 
-	resp, err := h.storage.Remaining().GetAllRemain(&models.GetAllRemainRequest{
-		Page:        page,
-		Limit:       limit,
-		Branch_id:   c.Query("search"),
-		Category_id: c.Query("search"),
-		Barcode:     c.Query("search"),
-	})
+	resp, err := h.storage.Remaining().GetAllRemain(c.Request.Context(), &req)
 	if err != nil {
-		h.log.Error("error Remain GetAllRemain:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.handleError(c, "error Remain GetAllRemain:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	response.List(c, http.StatusOK, resp.Remainings, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
 }
 
 // UpdateRemain godoc
@@ -163,33 +167,35 @@ func (h *Handler) GetAllRemain(c *gin.Context) {
 // @Summary      UPDATE Remain
 // @Description  UPDATES Remain BASED ON GIVEN DATA AND ID
 // @Tags         remain
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of Remain" format(uuid)
 // @Param        data  body      models.UpdateRemain  true  "Remain data"
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) UpdateRemain(c *gin.Context) {
 	var Remain models.UpdateRemain
 
-	err := c.ShouldBind(&Remain)
-	if err != nil {
-		h.log.Error("error while binding:", logger.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !h.bind(c, &Remain) {
 		return
 	}
 
 	Remain.ID = c.Param("id")
-	resp, err := h.storage.Remaining().UpdateRemain(&Remain)
+	resp, err := h.storage.Remaining().UpdateRemain(c.Request.Context(), &Remain)
 	if err != nil {
-		h.log.Error("error Remain update:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.handleError(c, "error Remain update:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": http.StatusOK, "message": "success", "resp": resp})
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
 }
 
 // DeleteRemain godoc
@@ -197,22 +203,27 @@ func (h *Handler) UpdateRemain(c *gin.Context) {
 // @Summary      DELETE Remain BY ID
 // @Description  deletes Remain by id
 // @Tags         remain
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id    path     string  true  "id of Remain" format(uuid)
-// @Success      200  {string}  string
-// @Failure      400  {object}  response.ErrorResp
-// @Failure      404  {object}  response.ErrorResp
-// @Failure      500  {object}  response.ErrorResp
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
 func (h *Handler) DeleteRemain(c *gin.Context) {
 	id := c.Param("id")
 
-	resp, err := h.storage.Remaining().DeleteRemain(&models.RemainIdRequest{Id: id})
+	resp, err := h.storage.Remaining().DeleteRemain(c.Request.Context(), &models.RemainIdRequest{Id: id})
 	if err != nil {
-		h.log.Error("error deleting Remain:", logger.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Remain"})
+		h.handleError(c, "error deleting Remain:", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Remain successfully deleted", "id": resp})
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
 }
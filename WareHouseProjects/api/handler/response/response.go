@@ -1,11 +1,73 @@
 package response
 
-type ErrorResp struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+import "github.com/gin-gonic/gin"
+
+// RequestIDKey is the gin context key and RequestIDHeader the HTTP header
+// carrying the id of the current request.
+const (
+	RequestIDKey    = "request_id"
+	RequestIDHeader = "X-Request-ID"
+)
+
+// CodeOK is the code of every successful response.
+const CodeOK = "OK"
+
+// Response is the envelope every endpoint answers with. Data is set on
+// success, Fields on validation failures and Meta on list endpoints.
+type Response struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Data      interface{}  `json:"data,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Meta      *Meta        `json:"meta,omitempty"`
+	RequestID string       `json:"request_id"`
+}
+
+// Meta describes one page of a list. Total is not counted when the page was
+// requested by cursor; NextCursor is empty on the last page.
+type Meta struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type IdResponse struct {
+	Id string `json:"id"`
+}
+
+// OK writes a successful envelope around data.
+func OK(c *gin.Context, status int, message string, data interface{}) {
+	c.JSON(status, Response{
+		Code:      CodeOK,
+		Message:   message,
+		Data:      data,
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
+
+// List writes a successful envelope around one page of data.
+func List(c *gin.Context, status int, data interface{}, meta Meta) {
+	c.JSON(status, Response{
+		Code:      CodeOK,
+		Message:   "success",
+		Data:      data,
+		Meta:      &meta,
+		RequestID: c.GetString(RequestIDKey),
+	})
 }
 
-type CreateResponse struct {
-	Id      string `json:"id"`
-	Message string `json:"message"`
+// Error aborts the request with an error envelope.
+func Error(c *gin.Context, status int, code, message string, fields ...FieldError) {
+	c.AbortWithStatusJSON(status, Response{
+		Code:      code,
+		Message:   message,
+		Fields:    fields,
+		RequestID: c.GetString(RequestIDKey),
+	})
 }
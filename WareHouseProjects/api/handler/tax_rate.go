@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTaxRate godoc
+// @Router       /tax_rate [POST]
+// @Summary      CREATE TAX RATE
+// @Description  adds a tax rate in percent, from 0 to 100
+// @Tags         tax_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateTaxRate  true  "tax rate data"
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateTaxRate(c *gin.Context) {
+	var rate models.CreateTaxRate
+	if !h.bind(c, &rate) {
+		return
+	}
+
+	resp, err := h.storage.TaxRate().CreateTaxRate(c.Request.Context(), &rate)
+	if err != nil {
+		h.handleError(c, "error tax rate create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
+}
+
+// GetTaxRate godoc
+// @Router       /tax_rate/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets tax rate by ID
+// @Tags         tax_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Tax rate ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.TaxRate}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetTaxRate(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.TaxRate().GetTaxRate(c.Request.Context(), &models.TaxRateIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get tax rate:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllTaxRate godoc
+// @Router       /tax_rate [GET]
+// @Summary      LIST TAX RATE
+// @Description  gets the tax rates, by name
+// @Tags         tax_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, rate, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        name            query     string    false  "search by name"
+// @Success      200  {object}  response.Response{data=[]models.TaxRate,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllTaxRate(c *gin.Context) {
+	var req models.GetAllTaxRateRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "tax_rates", &req.ListRequest, func() ([]models.TaxRate, string, error) {
+			resp, err := h.storage.TaxRate().GetAllTaxRate(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.TaxRates, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.TaxRate().GetAllTaxRate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error TaxRate GetAllTaxRate:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.TaxRates, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// UpdateTaxRate godoc
+// @Router       /tax_rate/{id} [PUT]
+// @Summary      UPDATE TAX RATE
+// @Description  changes a tax rate; lines already scanned keep the rate they were taxed at
+// @Tags         tax_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of tax rate" format(uuid)
+// @Param        data  body      models.UpdateTaxRate  true  "tax rate data"
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) UpdateTaxRate(c *gin.Context) {
+	var rate models.UpdateTaxRate
+	if !h.bind(c, &rate) {
+		return
+	}
+
+	rate.ID = c.Param("id")
+	resp, err := h.storage.TaxRate().UpdateTaxRate(c.Request.Context(), &rate)
+	if err != nil {
+		h.handleError(c, "error tax rate update:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
+}
+
+// DeleteTaxRate godoc
+// @Router       /tax_rate/{id} [DELETE]
+// @Summary      DELETE TAX RATE BY ID
+// @Description  deletes a tax rate no product or category is taxed at
+// @Tags         tax_rate
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of tax rate" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteTaxRate(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.TaxRate().DeleteTaxRate(c.Request.Context(), &models.TaxRateIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting tax rate:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
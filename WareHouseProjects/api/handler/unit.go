@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateUnit godoc
+// @Router       /unit [POST]
+// @Summary      CREATE UNIT
+// @Description  adds a unit of measure; precision is the number of decimals a count in it may have, 0 for whole units only
+// @Tags         unit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateUnit  true  "unit data"
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateUnit(c *gin.Context) {
+	var unit models.CreateUnit
+	if !h.bind(c, &unit) {
+		return
+	}
+
+	resp, err := h.storage.Unit().CreateUnit(c.Request.Context(), &unit)
+	if err != nil {
+		h.handleError(c, "error unit create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
+}
+
+// GetUnit godoc
+// @Router       /unit/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets unit by ID
+// @Tags         unit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Unit ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.Unit}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetUnit(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.Unit().GetUnit(c.Request.Context(), &models.UnitIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get unit:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllUnit godoc
+// @Router       /unit [GET]
+// @Summary      LIST UNIT
+// @Description  gets the units of measure, by name or short name
+// @Tags         unit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: name, short_name, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        name            query     string    false  "search by name or short name"
+// @Success      200  {object}  response.Response{data=[]models.Unit,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllUnit(c *gin.Context) {
+	var req models.GetAllUnitRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "units", &req.ListRequest, func() ([]models.Unit, string, error) {
+			resp, err := h.storage.Unit().GetAllUnit(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Units, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.Unit().GetAllUnit(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error Unit GetAllUnit:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.Units, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// UpdateUnit godoc
+// @Router       /unit/{id} [PUT]
+// @Summary      UPDATE UNIT
+// @Description  changes a unit; counts already stored are kept even when the new precision would not allow them
+// @Tags         unit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of unit" format(uuid)
+// @Param        data  body      models.UpdateUnit  true  "unit data"
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) UpdateUnit(c *gin.Context) {
+	var unit models.UpdateUnit
+	if !h.bind(c, &unit) {
+		return
+	}
+
+	unit.ID = c.Param("id")
+	resp, err := h.storage.Unit().UpdateUnit(c.Request.Context(), &unit)
+	if err != nil {
+		h.handleError(c, "error unit update:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
+}
+
+// DeleteUnit godoc
+// @Router       /unit/{id} [DELETE]
+// @Summary      DELETE UNIT BY ID
+// @Description  deletes a unit no product is counted in
+// @Tags         unit
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of unit" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteUnit(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.Unit().DeleteUnit(c.Request.Context(), &models.UnitIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting unit:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
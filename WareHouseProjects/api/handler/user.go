@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/auth"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateUser godoc
+// @Router       /user [POST]
+// @Summary      CREATE USER
+// @Description  adds a user who signs in with username and password. The role decides what the user may do; a user of a branch only sees the coming tables and stock of that branch, and a clerk must have one.
+// @Tags         user
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        data  body      models.CreateUser  true  "user data"
+// @Success      201  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) CreateUser(c *gin.Context) {
+	var user models.CreateUser
+	if !h.bind(c, &user) {
+		return
+	}
+	hash, err := auth.HashPassword(user.Password)
+	if err != nil {
+		h.handleError(c, "error user create:", err)
+		return
+	}
+	user.PasswordHash = hash
+
+	resp, err := h.storage.User().CreateUser(c.Request.Context(), &user)
+	if err != nil {
+		h.handleError(c, "error user create:", err)
+		return
+	}
+	response.OK(c, http.StatusCreated, "created", response.IdResponse{Id: resp})
+}
+
+// GetUser godoc
+// @Router       /user/{id} [GET]
+// @Summary      GET BY ID
+// @Description  gets user by ID
+// @Tags         user
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "User ID" format(uuid)
+// @Success      200  {object}  response.Response{data=models.User}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetUser(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.User().GetUser(c.Request.Context(), &models.UserIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error get user:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "success", resp)
+}
+
+// GetAllUser godoc
+// @Router       /user [GET]
+// @Summary      LIST USER
+// @Description  gets the users, by username, role and branch
+// @Tags         user
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param  		 limit         query     int        false  "limit, defaults to DEFAULT_LIMIT and is capped at MAX_LIMIT"          minimum(1)
+// @Param  		 page          query     int        false  "page"           minimum(1)     default(1)
+// @Param        sort          query     string     false  "field:asc|desc, field is one of: username, created_at" default(created_at:desc)
+// @Param        cursor        query     string     false  "next_cursor of the previous page, replaces page"
+// @Param        export        query     string     false  "answer with every matching row as a file instead of one page" Enums(csv, xlsx)
+// @Param        username        query     string    false  "search by username"
+// @Param        role            query     string    false  "role" Enums(admin, manager, clerk, auditor)
+// @Param        branch_id       query     string    false  "branch" format(uuid)
+// @Success      200  {object}  response.Response{data=[]models.User,meta=response.Meta}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) GetAllUser(c *gin.Context) {
+	var req models.GetAllUserRequest
+	if !h.bindQuery(c, &req) {
+		return
+	}
+	h.pageLimit(&req.Limit)
+
+	if req.Export != "" {
+		exportList(h, c, "users", &req.ListRequest, func() ([]models.User, string, error) {
+			resp, err := h.storage.User().GetAllUser(c.Request.Context(), &req)
+			if err != nil {
+				return nil, "", err
+			}
+			return resp.Users, resp.NextCursor, nil
+		})
+		return
+	}
+
+	resp, err := h.storage.User().GetAllUser(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, "error User GetAllUser:", err)
+		return
+	}
+
+	response.List(c, http.StatusOK, resp.Users, response.Meta{Page: req.Page, Limit: req.Limit, Total: resp.Count, NextCursor: resp.NextCursor})
+}
+
+// UpdateUser godoc
+// @Router       /user/{id} [PUT]
+// @Summary      UPDATE USER
+// @Description  changes a user; without a password the user keeps the current one
+// @Tags         user
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of user" format(uuid)
+// @Param        data  body      models.UpdateUser  true  "user data"
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      422  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) UpdateUser(c *gin.Context) {
+	var user models.UpdateUser
+	if !h.bind(c, &user) {
+		return
+	}
+	if user.Password != "" {
+		hash, err := auth.HashPassword(user.Password)
+		if err != nil {
+			h.handleError(c, "error user update:", err)
+			return
+		}
+		user.PasswordHash = hash
+	}
+
+	user.ID = c.Param("id")
+	resp, err := h.storage.User().UpdateUser(c.Request.Context(), &user)
+	if err != nil {
+		h.handleError(c, "error user update:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "updated", response.IdResponse{Id: resp})
+}
+
+// DeleteUser godoc
+// @Router       /user/{id} [DELETE]
+// @Summary      DELETE USER BY ID
+// @Description  deletes a user with its api keys
+// @Tags         user
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path     string  true  "id of user" format(uuid)
+// @Success      200  {object}  response.Response{data=response.IdResponse}
+// @Failure      400  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+func (h *Handler) DeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.storage.User().DeleteUser(c.Request.Context(), &models.UserIdRequest{Id: id})
+	if err != nil {
+		h.handleError(c, "error deleting user:", err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "deleted", response.IdResponse{Id: resp})
+}
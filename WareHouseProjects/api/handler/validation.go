@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"WareHouseProjects/api/handler/response"
+	"WareHouseProjects/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// Report fields by their JSON name rather than the Go one.
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		// Let gt, gte and omitempty check decimals like numbers.
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if d, ok := field.Interface().(decimal.Decimal); ok {
+				return d.InexactFloat64()
+			}
+			return nil
+		}, decimal.Decimal{})
+	}
+}
+
+// bind decodes the request body into obj and validates it against its
+// binding tags. On failure it writes the error response and returns false.
+func (h *Handler) bind(c *gin.Context, obj interface{}) bool {
+	return h.checkBinding(c, c.ShouldBind(obj), "invalid body")
+}
+
+// bindQuery is bind for the URL query string.
+func (h *Handler) bindQuery(c *gin.Context, obj interface{}) bool {
+	return h.checkBinding(c, c.ShouldBindQuery(obj), "invalid query")
+}
+
+// pageLimit applies the configured default and maximum page size to limit.
+func (h *Handler) pageLimit(limit *int) {
+	if *limit == 0 {
+		*limit = h.cfg.DefaultLimit
+	}
+	if *limit > h.cfg.MaxLimit {
+		*limit = h.cfg.MaxLimit
+	}
+}
+
+func (h *Handler) checkBinding(c *gin.Context, err error, message string) bool {
+	if err == nil {
+		return true
+	}
+
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		fields         []response.FieldError
+	)
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, response.FieldError{Field: fe.Field(), Reason: reason(fe)})
+		}
+	case errors.As(err, &typeErr):
+		fields = append(fields, response.FieldError{Field: typeErr.Field, Reason: "must be a " + typeErr.Type.String()})
+	default:
+		h.badRequest(c, message, err)
+		return false
+	}
+
+	h.log.Warn("request validation failed", logger.Error(err))
+	response.Error(c, http.StatusUnprocessableEntity, CodeValidation, "request validation failed", fields...)
+	return false
+}
+
+func reason(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "uuid":
+		return "must be a valid UUID"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "min":
+		return "must be at least " + fe.Param()
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", fe.Param())
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +998901234567"
+	case "datetime":
+		return "must be a date in the format " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " check"
+}
@@ -10,50 +10,136 @@ import (
 )
 
 func NewServer(h *handler.Handler) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Logger(), h.RequestID(), h.Recovery(), h.DBTimeout())
+	r.NoRoute(h.NoRoute)
+
+	//Health
+	r.GET("/health", h.Health)
+
+	//Auth
+	r.POST("/auth/login", h.Login)
+	r.POST("/auth/refresh", h.RefreshToken)
+	r.POST("/auth/logout", h.Logout)
+
+	//Swagger
+	url := ginSwagger.URL("swagger/doc.json")
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+
+	// Every other route requires an access token or an api key, and a role
+	// allowed to call its method on the object it is guarded as.
+	a := r.Group("", h.Authenticate())
+	var g *gin.RouterGroup
+
 	//Branches
-	r.POST("/branch", h.CreateBranch)
-	r.GET("/branch/:id", h.GetBranch)
-	r.GET("/branch", h.GetAllBranch)
-	r.PUT("/branch/:id", h.UpdateBranch)
-	r.DELETE("/branch/:id", h.DeleteBranch)
+	g = a.Group("", h.Authorize("branch"))
+	g.POST("/branch", h.CreateBranch)
+	g.GET("/branch/:id", h.GetBranch)
+	g.GET("/branch", h.GetAllBranch)
+	g.PUT("/branch/:id", h.UpdateBranch)
+	g.DELETE("/branch/:id", h.DeleteBranch)
+
+	//BranchPrice
+	g = a.Group("", h.Authorize("branch_price"))
+	g.POST("/branch_price", h.CreateBranchPrice)
+	g.GET("/branch_price/:id", h.GetBranchPrice)
+	g.GET("/branch_price", h.GetAllBranchPrice)
+	g.PUT("/branch_price/:id", h.UpdateBranchPrice)
+	g.DELETE("/branch_price/:id", h.DeleteBranchPrice)
 
 	//Categories
-	r.POST("/category", h.CreateCategory)
-	r.GET("/category/:id", h.GetCategory)
-	r.GET("/category", h.GetAllCategory)
-	r.PUT("/category/:id", h.UpdateCategory)
-	r.DELETE("/category/:id", h.DeleteCategory)
+	g = a.Group("", h.Authorize("category"))
+	g.POST("/category", h.CreateCategory)
+	g.GET("/category/:id", h.GetCategory)
+	g.GET("/category", h.GetAllCategory)
+	g.PUT("/category/:id", h.UpdateCategory)
+	g.DELETE("/category/:id", h.DeleteCategory)
+
+	//ExchangeRate
+	g = a.Group("", h.Authorize("exchange_rate"))
+	g.POST("/exchange_rate", h.CreateExchangeRate)
+	g.GET("/exchange_rate/:id", h.GetExchangeRate)
+	g.GET("/exchange_rate", h.GetAllExchangeRate)
+	g.PUT("/exchange_rate/:id", h.UpdateExchangeRate)
+	g.DELETE("/exchange_rate/:id", h.DeleteExchangeRate)
+
+	//Unit
+	g = a.Group("", h.Authorize("unit"))
+	g.POST("/unit", h.CreateUnit)
+	g.GET("/unit/:id", h.GetUnit)
+	g.GET("/unit", h.GetAllUnit)
+	g.PUT("/unit/:id", h.UpdateUnit)
+	g.DELETE("/unit/:id", h.DeleteUnit)
+
+	//TaxRate
+	g = a.Group("", h.Authorize("tax_rate"))
+	g.POST("/tax_rate", h.CreateTaxRate)
+	g.GET("/tax_rate/:id", h.GetTaxRate)
+	g.GET("/tax_rate", h.GetAllTaxRate)
+	g.PUT("/tax_rate/:id", h.UpdateTaxRate)
+	g.DELETE("/tax_rate/:id", h.DeleteTaxRate)
 
 	//Product
-	r.POST("/product", h.CreateProduct)
-	r.GET("/product/:id", h.GetProduct)
-	r.GET("/product", h.GetAllProduct)
-	r.PUT("/product/:id", h.UpdateProduct)
-	r.DELETE("/product/:id", h.DeleteProduct)
+	g = a.Group("", h.Authorize("product"))
+	g.POST("/product", h.CreateProduct)
+	g.POST("/product/import", h.ImportProduct)
+	g.GET("/product/search", h.SearchProduct)
+	g.GET("/product/barcode/:barcode", h.GetProductByBarcode)
+	g.GET("/product/:id", h.GetProduct)
+	g.GET("/product", h.GetAllProduct)
+	g.PUT("/product/:id", h.UpdateProduct)
+	g.DELETE("/product/:id", h.DeleteProduct)
+
+	//ProductPrice
+	g = a.Group("", h.Authorize("product_price"))
+	g.POST("/product/:id/price", h.CreateProductPrice)
+	g.GET("/product_price", h.GetAllProductPrice)
+	g.DELETE("/product_price/:id", h.DeleteProductPrice)
 
 	//ComingTable
-	r.POST("/coming_table", h.CreateComingTable)
-	r.GET("/coming_table/:id", h.GetComingTable)
-	r.GET("/coming_table", h.GetAllComingTable)
-	r.PUT("/coming_table/:id", h.UpdateComingTable)
-	r.DELETE("/coming_table/:id", h.DeleteComingTable)
+	g = a.Group("", h.Authorize("coming_table"))
+	g.POST("/coming_table", h.CreateComingTable)
+	g.GET("/coming_table/:id", h.GetComingTable)
+	g.GET("/coming_table", h.GetAllComingTable)
+	g.PUT("/coming_table/:id", h.UpdateComingTable)
+	g.DELETE("/coming_table/:id", h.DeleteComingTable)
+	g.POST("/coming_table/:id/import", h.ImportComingTableProduct)
+	g.GET("/coming_table/:id/pdf", h.ComingTablePDF)
 
 	//ComingTableProduct
-	r.POST("/coming_table_product", h.CreateComingTableProduct)
-	r.GET("/coming_table_product/:id", h.GetComingTableProduct)
-	r.GET("/coming_table_product", h.GetAllComingTableProduct)
-	r.PUT("/coming_table_product/:id", h.UpdateComingTableProduct)
-	r.DELETE("/coming_table_product/:id", h.DeleteComingTableProduct)
+	g = a.Group("", h.Authorize("coming_table_product"))
+	g.POST("/coming_table_product", h.CreateComingTableProduct)
+	g.GET("/coming_table_product/:id", h.GetComingTableProduct)
+	g.GET("/coming_table_product", h.GetAllComingTableProduct)
+	g.PUT("/coming_table_product/:id", h.UpdateComingTableProduct)
+	g.DELETE("/coming_table_product/:id", h.DeleteComingTableProduct)
 
 	//Remain
-	r.POST("/do_income/:coming_table_id", h.CreateRemain)
-	r.GET("/remain/:id", h.GetRemain)
-	r.GET("/remain", h.GetAllRemain)
-	r.PUT("/remain/:id", h.UpdateRemain)
-	r.DELETE("/remain/:id", h.DeleteRemain)
+	g = a.Group("", h.Authorize("remain"))
+	g.POST("/do_income/:coming_table_id", h.CreateRemain)
+	g.GET("/remain/:id", h.GetRemain)
+	g.GET("/remain", h.GetAllRemain)
+	g.PUT("/remain/:id", h.UpdateRemain)
+	g.DELETE("/remain/:id", h.DeleteRemain)
+
+	//User
+	g = a.Group("", h.Authorize("user"))
+	g.POST("/user", h.CreateUser)
+	g.GET("/user/:id", h.GetUser)
+	g.GET("/user", h.GetAllUser)
+	g.PUT("/user/:id", h.UpdateUser)
+	g.DELETE("/user/:id", h.DeleteUser)
+
+	//ApiKey
+	g = a.Group("", h.Authorize("api_key"))
+	g.POST("/api_key", h.CreateApiKey)
+	g.GET("/api_key/:id", h.GetApiKey)
+	g.GET("/api_key", h.GetAllApiKey)
+	g.DELETE("/api_key/:id", h.DeleteApiKey)
+
+	//Audit
+	g = a.Group("", h.Authorize("audit"))
+	g.GET("/audit", h.GetAllAudit)
 
-	url := ginSwagger.URL("swagger/doc.json")
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 	return r
 }
@@ -4,23 +4,141 @@ import (
 	"WareHouseProjects/api"
 	"WareHouseProjects/api/handler"
 	"WareHouseProjects/config"
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/auth"
 	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
 	postgres "WareHouseProjects/storage/postgress"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 )
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by an access token from /auth/login
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
 func main() {
-	fmt.Println("start")
 	cfg := config.Load()
 	log := logger.NewLogger("warehouse_project", logger.LevelInfo)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(cfg, os.Args[2:]); err != nil {
+			log.Error("migrate:", logger.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	// A secret made up at startup signs tokens that no other instance accepts
+	// and that stop working at the next restart; fine while developing only.
+	if cfg.JWTSecret == "" && cfg.Environment == config.ReleaseMode {
+		log.Error("JWT_SECRET must be set in release mode")
+		os.Exit(1)
+	}
+	if cfg.JWTSecret == "" {
+		secret, err := auth.NewSecret()
+		if err != nil {
+			log.Error("jwt secret:", logger.Error(err))
+			os.Exit(1)
+		}
+		cfg.JWTSecret = string(secret)
+		log.Warn("JWT_SECRET is not set, tokens will not survive a restart")
+	}
+
+	fmt.Println("start")
 	strg, err := postgres.NewStorage(context.Background(), cfg)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	h := handler.NewHandler(strg, log)
+	if err := createAdmin(context.Background(), cfg, strg); err != nil {
+		log.Error("create admin:", logger.Error(err))
+		os.Exit(1)
+	}
+	go applyPrices(context.Background(), cfg, strg, log)
+
+	h := handler.NewHandler(cfg, strg, log)
 
 	r := api.NewServer(h)
 	r.Run(cfg.Port)
 }
+
+// migrate runs `migrate up|down|status` against the configured database.
+func migrate(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s migrate up|down|status", os.Args[0])
+	}
+
+	ctx := context.Background()
+	m, err := postgres.NewMigrator(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied %03d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println("no applied migrations")
+			return nil
+		}
+		fmt.Printf("reverted %03d_%s\n", reverted.Version, reverted.Name)
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range list {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			fmt.Printf("%03d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	return nil
+}
+
+// createAdmin creates the user ADMIN_USERNAME with ADMIN_PASSWORD unless it
+// exists, so that a fresh database can be signed in to. It does nothing when
+// ADMIN_PASSWORD is not set.
+func createAdmin(ctx context.Context, cfg config.Config, strg storage.StorageI) error {
+	if cfg.AdminPassword == "" {
+		return nil
+	}
+
+	_, err := strg.User().GetUserCredentials(ctx, cfg.AdminUsername)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	hash, err := auth.HashPassword(cfg.AdminPassword)
+	if err != nil {
+		return err
+	}
+	_, err = strg.User().CreateUser(ctx, &models.CreateUser{Username: cfg.AdminUsername, PasswordHash: hash, Role: models.RoleAdmin})
+	return err
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"WareHouseProjects/config"
+	"WareHouseProjects/pkg/logger"
+	"WareHouseProjects/storage"
+	"context"
+	"time"
+)
+
+// applyPrices applies scheduled price changes as they become due, once every
+// cfg.PriceSchedulerInterval, until ctx is done.
+func applyPrices(ctx context.Context, cfg config.Config, strg storage.StorageI, log logger.LoggerI) {
+	ticker := time.NewTicker(cfg.PriceSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := strg.ProductPrice().ApplyDuePrices(ctx)
+		if err != nil {
+			log.Error("error applying due prices:", logger.Error(err))
+		} else if applied > 0 {
+			log.Info("applied scheduled prices", logger.Int("count", applied))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
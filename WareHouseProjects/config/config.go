@@ -10,10 +10,15 @@ import (
 )
 
 type Config struct {
-	Limit   int
-	Page    int
+	Limit int
+	Page  int
+
+	// Objects are what routes are guarded as and Methods the HTTP methods
+	// they are called with. Policy grants each role, by object, the methods
+	// its users may call.
 	Methods []string
 	Objects []string
+	Policy  map[string]map[string][]string
 
 	Environment string // debug, test, release
 
@@ -27,8 +32,43 @@ type Config struct {
 
 	PostgresMaxConnections int32
 
+	// DBTimeout bounds how long the queries of a single HTTP request may run.
+	// ExportTimeout does the same for a list exported as a file, which reads
+	// every matching row and is streamed while it is read.
+	DBTimeout     time.Duration
+	ExportTimeout time.Duration
+
 	DefaultOffset int
-	DefaultLimit  int
+	// DefaultLimit is the page size of list endpoints called without limit,
+	// MaxLimit the largest page size a caller may ask for.
+	DefaultLimit int
+	MaxLimit     int
+
+	// PriceSchedulerInterval is how often scheduled price changes that have
+	// become due are applied.
+	PriceSchedulerInterval time.Duration
+
+	// BaseCurrency is the currency stock is valued in. Coming tables in
+	// another currency are converted to it at their exchange rate.
+	BaseCurrency string
+
+	// DocumentNumberFormat lays out document numbers. {branch} is replaced
+	// by the branch code, {type} by the document type, {year} by the year of
+	// the document and {number} by its number in that year, zero-padded to
+	// DocumentNumberDigits digits.
+	DocumentNumberFormat string
+	DocumentNumberDigits int
+
+	// JWTSecret signs access and refresh tokens and is required in release
+	// mode. Access tokens expire after AccessTokenTTL, refresh tokens after
+	// TimeExpiredAt.
+	JWTSecret      string
+	AccessTokenTTL time.Duration
+
+	// AdminUsername and AdminPassword, when the password is set, are the
+	// user created at startup if no user by that name exists yet.
+	AdminUsername string
+	AdminPassword string
 }
 
 const (
@@ -70,10 +110,72 @@ func Load() Config {
 	config.PostgresDatabase = cast.ToString(getOrReturnDefaultValue("POSTGRES_DATABASE", "market_miniproject"))
 
 	config.PostgresMaxConnections = cast.ToInt32(getOrReturnDefaultValue("POSTGRES_MAX_CONNECTIONS", 30))
+	config.DBTimeout = cast.ToDuration(getOrReturnDefaultValue("DB_TIMEOUT", "10s"))
+	config.ExportTimeout = cast.ToDuration(getOrReturnDefaultValue("EXPORT_TIMEOUT", "5m"))
+
+	config.DefaultLimit = cast.ToInt(getOrReturnDefaultValue("DEFAULT_LIMIT", 10))
+	config.MaxLimit = cast.ToInt(getOrReturnDefaultValue("MAX_LIMIT", 100))
+
+	config.PriceSchedulerInterval = cast.ToDuration(getOrReturnDefaultValue("PRICE_SCHEDULER_INTERVAL", "1m"))
+
+	config.BaseCurrency = cast.ToString(getOrReturnDefaultValue("BASE_CURRENCY", "UZS"))
+
+	config.DocumentNumberFormat = cast.ToString(getOrReturnDefaultValue("DOCUMENT_NUMBER_FORMAT", "{branch}-{year}-{number}"))
+	config.DocumentNumberDigits = cast.ToInt(getOrReturnDefaultValue("DOCUMENT_NUMBER_DIGITS", 6))
+
+	config.JWTSecret = cast.ToString(getOrReturnDefaultValue("JWT_SECRET", ""))
+	config.AccessTokenTTL = cast.ToDuration(getOrReturnDefaultValue("ACCESS_TOKEN_TTL", "15m"))
+
+	config.AdminUsername = cast.ToString(getOrReturnDefaultValue("ADMIN_USERNAME", "admin"))
+	config.AdminPassword = cast.ToString(getOrReturnDefaultValue("ADMIN_PASSWORD", ""))
+
+	config.Methods = []string{"GET", "POST", "PUT", "DELETE"}
+	config.Objects = []string{
+		"branch", "branch_price", "category", "exchange_rate", "unit", "tax_rate",
+		"product", "product_price", "coming_table", "coming_table_product", "remain",
+		"user", "api_key", "audit",
+	}
+	config.Policy = defaultPolicy(config.Objects, config.Methods)
 
 	return config
 }
 
+// defaultPolicy lets admins do everything and managers everything but manage
+// users and API keys. Auditors read everything but API keys. Clerks receive
+// goods: they keep coming tables and stock and read the catalog, but not the
+// audit log.
+func defaultPolicy(objects, methods []string) map[string]map[string][]string {
+	read := []string{"GET"}
+	policy := map[string]map[string][]string{
+		"admin":   {},
+		"manager": {},
+		"auditor": {},
+		"clerk": {
+			"coming_table":         methods,
+			"coming_table_product": methods,
+			"remain":               {"GET", "POST", "PUT"},
+		},
+	}
+	for _, object := range objects {
+		policy["admin"][object] = methods
+		switch object {
+		case "api_key":
+		case "audit":
+			policy["manager"][object] = read
+			policy["auditor"][object] = read
+		case "user":
+			policy["auditor"][object] = read
+		default:
+			policy["manager"][object] = methods
+			policy["auditor"][object] = read
+			if _, ok := policy["clerk"][object]; !ok {
+				policy["clerk"][object] = read
+			}
+		}
+	}
+	return policy
+}
+
 func getOrReturnDefaultValue(key string, defaultValue interface{}) interface{} {
 	val, exists := os.LookupEnv(key)
 	if exists {
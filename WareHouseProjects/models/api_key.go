@@ -0,0 +1,42 @@
+package models
+
+// CreateApiKey issues an API key acting as User_id, the caller by default.
+// Prefix and KeyHash are filled by the server.
+type CreateApiKey struct {
+	Name    string `json:"name" binding:"required,max=255"`
+	User_id string `json:"user_id" binding:"omitempty,uuid"`
+	Prefix  string `json:"-"`
+	KeyHash string `json:"-"`
+}
+
+// ApiKey is an API key of a device. The key itself is only shown when it is
+// created; Prefix is its start.
+type ApiKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	User_id    string `json:"user_id"`
+	Prefix     string `json:"prefix"`
+	LastUsedAt string `json:"last_used_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CreatedApiKey is a new API key with the key to send in X-API-Key.
+type CreatedApiKey struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+type ApiKeyIdRequest struct {
+	Id string `json:"id"`
+}
+
+type GetAllApiKeyRequest struct {
+	ListRequest
+	User_id string `json:"user_id" form:"user_id" binding:"omitempty,uuid"`
+}
+
+type GetAllApiKeyResponse struct {
+	ApiKeys    []ApiKey `json:"api_key"`
+	Count      int      `json:"count"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
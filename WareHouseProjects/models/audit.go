@@ -0,0 +1,45 @@
+package models
+
+import "encoding/json"
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEntry is a change of an entity. Before is empty for a create and After
+// for a delete; both are the stored row, without password and API key hashes.
+// Actor_id and Actor are empty for changes the server made on its own, such
+// as applying scheduled prices, and Actor for users deleted since.
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	Actor_id   string          `json:"actor_id"`
+	Actor      string          `json:"actor"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     AuditAction     `json:"action"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// GetAllAuditRequest filters the audit log. From and To bound the time of the
+// change, From included and To excluded, in RFC 3339.
+type GetAllAuditRequest struct {
+	ListRequest
+	EntityType string      `json:"entity_type" form:"entity_type" binding:"omitempty,oneof=branch branch_price category exchange_rate unit tax_rate product product_price coming_table coming_table_product remain user api_key"`
+	EntityID   string      `json:"entity_id" form:"entity_id" binding:"omitempty,uuid"`
+	Actor_id   string      `json:"actor_id" form:"actor_id" binding:"omitempty,uuid"`
+	Action     AuditAction `json:"action" form:"action" binding:"omitempty,oneof=create update delete"`
+	From       string      `json:"from" form:"from" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string      `json:"to" form:"to" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type GetAllAuditResponse struct {
+	Entries    []AuditEntry `json:"audit"`
+	Count      int          `json:"count"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse is a pair of tokens. The access token is sent as
+// "Authorization: Bearer <access_token>" and expires after ExpiresIn
+// seconds; the refresh token gets the next pair, once.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// CreateRefreshToken records an issued refresh token of UserID that is
+// valid for TTL seconds.
+type CreateRefreshToken struct {
+	ID     string
+	UserID string
+	TTL    int
+}
@@ -1,14 +1,17 @@
 package models
 
 type CreateBranch struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Phone   string `json:"phone"`
+	Name    string `json:"name" binding:"required,max=255"`
+	Code    string `json:"code" binding:"required,max=16,alphanum,uppercase"`
+	Address string `json:"address" binding:"max=255"`
+	Phone   string `json:"phone" binding:"omitempty,e164"`
 }
 
+// Branch is a warehouse. Its Code prefixes the numbers of its documents.
 type Branch struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
+	Code      string `json:"code"`
 	Address   string `json:"address"`
 	Phone     string `json:"phone"`
 	CreatedAt string `json:"created_at"`
@@ -16,9 +19,10 @@ type Branch struct {
 }
 type UpdateBranch struct {
 	Id      string `json:"id"`
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Phone   string `json:"phone"`
+	Name    string `json:"name" binding:"required,max=255"`
+	Code    string `json:"code" binding:"required,max=16,alphanum,uppercase"`
+	Address string `json:"address" binding:"max=255"`
+	Phone   string `json:"phone" binding:"omitempty,e164"`
 }
 
 type BranchIdRequest struct {
@@ -26,12 +30,12 @@ type BranchIdRequest struct {
 }
 
 type GetAllBranchRequest struct {
-	Page  int    `json:"page"`
-	Limit int    `json:"limit"`
-	Name  string `json:"name"`
+	ListRequest
+	Name string `json:"name" form:"search"`
 }
 
 type GetAllBranchResponse struct {
-	Branches []Branch `json:"branches"`
-	Count    int      `json:"count"`
+	Branches   []Branch `json:"branches"`
+	Count      int      `json:"count"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+type CreateBranchPrice struct {
+	Branch_id  string          `json:"branch_id" binding:"required,uuid"`
+	Product_id string          `json:"product_id" binding:"required,uuid"`
+	Price      decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+}
+
+// BranchPrice is the price a branch sells a product at instead of the
+// product price.
+type BranchPrice struct {
+	ID           string          `json:"id"`
+	Branch_id    string          `json:"branch_id"`
+	Product_id   string          `json:"product_id"`
+	ProductName  string          `json:"product_name"`
+	Price        decimal.Decimal `json:"price" swaggertype:"number"`
+	ProductPrice decimal.Decimal `json:"product_price" swaggertype:"number"`
+	CreatedAt    string          `json:"created_at"`
+	UpdatedAt    string          `json:"updated_at"`
+}
+
+type UpdateBranchPrice struct {
+	ID    string          `json:"id"`
+	Price decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+}
+
+type BranchPriceIdRequest struct {
+	Id string `json:"id"`
+}
+
+type GetAllBranchPriceRequest struct {
+	ListRequest
+	Branch_id  string `json:"branch_id" form:"branch_id" binding:"omitempty,uuid"`
+	Product_id string `json:"product_id" form:"product_id" binding:"omitempty,uuid"`
+}
+
+type GetAllBranchPriceResponse struct {
+	BranchPrices []BranchPrice `json:"branch_price"`
+	Count        int           `json:"count"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
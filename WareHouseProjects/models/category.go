@@ -1,16 +1,20 @@
 package models
 
 type CreateCategory struct {
-	Name      string `json:"name"`
-	Parent_id string `json:"parent_id"`
+	Name        string `json:"name" binding:"required,max=255"`
+	Parent_id   string `json:"parent_id" binding:"omitempty,uuid"`
+	Tax_rate_id string `json:"tax_rate_id" binding:"omitempty,uuid"`
 }
 
+// Category groups products. Its tax rate applies to the products in it and
+// its subcategories that have none of their own.
 type Category struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Parent_id string `json:"parent_id"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Parent_id   string `json:"parent_id"`
+	Tax_rate_id string `json:"tax_rate_id"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
 }
 
 type CategoryIdRequest struct {
@@ -18,17 +22,19 @@ type CategoryIdRequest struct {
 }
 
 type UpdateCategory struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
-	Parent_id string `json:"parent_id"`
+	Id          string `json:"id"`
+	Name        string `json:"name" binding:"required,max=255"`
+	Parent_id   string `json:"parent_id" binding:"omitempty,uuid"`
+	Tax_rate_id string `json:"tax_rate_id" binding:"omitempty,uuid"`
 }
 type GetAllCategoryRequest struct {
-	Page  int    `json:"page"`
-	Limit int    `json:"limit"`
-	Name  string `json:"name"`
+	ListRequest
+	Name      string `json:"name" form:"search"`
+	Parent_id string `json:"parent_id" form:"parent_id" binding:"omitempty,uuid"`
 }
 
 type GetAllCategoryResponse struct {
 	Categories []Category `json:"category"`
 	Count      int        `json:"count"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
@@ -1,32 +1,62 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type TableType string
 
 const (
-	Finishied TableType = "finishied"
+	Finished  TableType = "finished"
 	InProcess TableType = "in_process"
 )
 
+// CreateComingTable opens a coming table. Currency defaults to the base
+// currency; ExchangeRate defaults to the rate of Currency on DateTime.
+// Coming_id is the document number, given by the server.
 type CreateComingTable struct {
-	Coming_id string `json:"coming_id"`
-	Branch_id string `json:"branch_id"`
-	DateTime  string `json:"date_time"`
+	Coming_id    string          `json:"-"`
+	Branch_id    string          `json:"branch_id" binding:"required,uuid"`
+	DateTime     string          `json:"date_time" binding:"required,datetime=2006-01-02 15:04:05"`
+	Currency     string          `json:"currency" binding:"omitempty,len=3,uppercase"`
+	ExchangeRate decimal.Decimal `json:"exchange_rate" binding:"omitempty,gt=0" swaggertype:"number"`
 }
 
+// ComingTable is an arrival document invoiced in Currency. ExchangeRate is
+// how many units of the base currency one unit of Currency is worth.
+// LineCount, TotalCount and TotalPrice sum up its lines, DocTotalPrice in
+// Currency. NetAmount, TaxAmount and GrossAmount total the lines in the base
+// currency; Taxes breaks them down by tax rate. Lines and Taxes are only
+// filled for a single coming table.
 type ComingTable struct {
-	ID        string    `json:"id"`
-	ComingID  string    `json:"coming_id"`
-	BranchID  string    `json:"branch_id"`
-	DateTime  string    `json:"date_time"`
-	Status    TableType `json:"status"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
+	ID            string               `json:"id"`
+	ComingID      string               `json:"coming_id"`
+	BranchID      string               `json:"branch_id"`
+	DateTime      string               `json:"date_time"`
+	Status        TableType            `json:"status"`
+	Currency      string               `json:"currency"`
+	ExchangeRate  decimal.Decimal      `json:"exchange_rate" swaggertype:"number"`
+	LineCount     int                  `json:"line_count"`
+	TotalCount    decimal.Decimal      `json:"total_count" swaggertype:"number"`
+	TotalPrice    decimal.Decimal      `json:"total_price" swaggertype:"number"`
+	DocTotalPrice decimal.Decimal      `json:"doc_total_price" swaggertype:"number"`
+	NetAmount     decimal.Decimal      `json:"net_amount" swaggertype:"number"`
+	TaxAmount     decimal.Decimal      `json:"tax_amount" swaggertype:"number"`
+	GrossAmount   decimal.Decimal      `json:"gross_amount" swaggertype:"number"`
+	Lines         []ComingTableProduct `json:"lines,omitempty"`
+	Taxes         []ComingTableTax     `json:"taxes,omitempty"`
+	CreatedAt     string               `json:"created_at"`
+	UpdatedAt     string               `json:"updated_at"`
 }
+
+// UpdateComingTable overwrites a coming table like CreateComingTable creates
+// one. The costs of its lines are converted again at the new exchange rate.
+// The document number stays the one it was given, so BranchID and the year of
+// DateTime must stay those it was numbered in.
 type UpdateComingTable struct {
-	ID       string `json:"id"`
-	ComingID string `json:"coming_id"`
-	BranchID string `json:"branch_id"`
-	DateTime string `json:"date_time"`
+	ID           string          `json:"id"`
+	BranchID     string          `json:"branch_id" binding:"required,uuid"`
+	DateTime     string          `json:"date_time" binding:"required,datetime=2006-01-02 15:04:05"`
+	Currency     string          `json:"currency" binding:"omitempty,len=3,uppercase"`
+	ExchangeRate decimal.Decimal `json:"exchange_rate" binding:"omitempty,gt=0" swaggertype:"number"`
 }
 
 type ComingTableIdRequest struct {
@@ -34,13 +64,21 @@ type ComingTableIdRequest struct {
 }
 
 type GetAllComingTableRequest struct {
-	Page     int    `json:"page"`
-	Limit    int    `json:"limit"`
-	ComingID string `json:"coming_id"`
-	BranchID string `json:"branch_id"`
+	ListRequest
+	ComingID string    `json:"coming_id" form:"coming_id"`
+	BranchID string    `json:"branch_id" form:"branch_id" binding:"omitempty,uuid"`
+	Status   TableType `json:"status" form:"status" binding:"omitempty,oneof=in_process finished"`
+	Currency string    `json:"currency" form:"currency" binding:"omitempty,len=3,uppercase"`
+	DateFrom string    `json:"date_from" form:"date_from" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   string    `json:"date_to" form:"date_to" binding:"omitempty,datetime=2006-01-02"`
+
+	// InBaseCurrency keeps the coming tables in the base currency, which are
+	// stored without one, instead of those in Currency.
+	InBaseCurrency bool `json:"-" form:"-"`
 }
 
 type GetAllComingTableResponse struct {
 	ComingTables []ComingTable `json:"coming_table"`
 	Count        int           `json:"count"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
 }
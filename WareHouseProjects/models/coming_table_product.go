@@ -1,61 +1,102 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type CreateComingTableProduct struct {
-	Category_id     string  `json:"category_id"`
-	Name            string  `json:"name"`
-	Price           float64 `json:"price"`
-	Barcode         string  `json:"barcode"`
-	Count           float64 `json:"count"`
-	TotalPrice      float64 `json:"total_price"`
-	Coming_Table_id string  `json:"coming_table_id"`
+	Category_id     string          `json:"category_id"`
+	Name            string          `json:"name"`
+	Price           decimal.Decimal `json:"price" swaggertype:"number"`
+	Cost            decimal.Decimal `json:"cost" binding:"omitempty,gt=0" swaggertype:"number"`
+	DocCost         decimal.Decimal `json:"doc_cost" swaggertype:"number"`
+	Barcode         string          `json:"barcode" binding:"required,max=64"`
+	Count           decimal.Decimal `json:"count" binding:"gt=0" swaggertype:"number"`
+	TotalPrice      decimal.Decimal `json:"total_price" swaggertype:"number"`
+	DocTotalPrice   decimal.Decimal `json:"doc_total_price" swaggertype:"number"`
+	TaxRate         decimal.Decimal `json:"tax_rate" swaggertype:"number"`
+	Coming_Table_id string          `json:"coming_table_id" binding:"required,uuid"`
 }
 
+// CheckBarcodeComingTable looks a product up by barcode. Its price is the one
+// of Branch_id, or of the branch of Coming_Table_id, when that branch
+// overrides it.
 type CheckBarcodeComingTable struct {
 	Barcode         string `json:"barcode"`
 	Coming_Table_id string `json:"coming_table_id"`
+	Branch_id       string `json:"branch_id" form:"branch_id" binding:"omitempty,uuid"`
 }
 
+// CreateComingTableProductSwagger is the body of a new arrival line. Cost is
+// the purchase cost per unit in the currency of the coming table; it defaults
+// to the product's last purchase cost, or its selling price for a product
+// never received before.
 type CreateComingTableProductSwagger struct {
-	Barcode         string  `json:"barcode"`
-	Coming_Table_id string  `json:"coming_table_id"`
-	Count           float64 `json:"count"`
+	Barcode         string          `json:"barcode" binding:"required,max=64"`
+	Coming_Table_id string          `json:"coming_table_id" binding:"required,uuid"`
+	Count           decimal.Decimal `json:"count" binding:"gt=0" swaggertype:"number"`
+	Cost            decimal.Decimal `json:"cost" binding:"omitempty,gt=0" swaggertype:"number"`
 }
+
+// ComingTableProduct is an arrival line. Price is the selling price of the
+// product when it was scanned, Cost the purchase cost per unit and TotalPrice
+// the line valued at cost, both in the base currency; DocCost and
+// DocTotalPrice are the same in the currency of the coming table. Unit and
+// Precision are those of the product. The line is taxed at TaxRate percent of
+// the product when it was scanned: NetAmount is TotalPrice, TaxAmount is
+// rounded to hundredths and GrossAmount is their sum.
 type ComingTableProduct struct {
-	ID              string  `json:"id"`
-	Category_id     string  `json:"category_id"`
-	Name            string  `json:"name"`
-	Price           float64 `json:"price"`
-	Barcode         string  `json:"barcode"`
-	Count           float64 `json:"count"`
-	TotalPrice      float64 `json:"total_price"`
-	Coming_Table_id string  `json:"coming_table_id"`
-	CreatedAt       string  `json:"created_at"`
-	UpdatedAt       string  `json:"updated_at"`
+	ID              string          `json:"id"`
+	Category_id     string          `json:"category_id"`
+	Name            string          `json:"name"`
+	Price           decimal.Decimal `json:"price" swaggertype:"number"`
+	Cost            decimal.Decimal `json:"cost" swaggertype:"number"`
+	DocCost         decimal.Decimal `json:"doc_cost" swaggertype:"number"`
+	Barcode         string          `json:"barcode"`
+	Count           decimal.Decimal `json:"count" swaggertype:"number"`
+	Unit            string          `json:"unit"`
+	Precision       *int            `json:"precision"`
+	TotalPrice      decimal.Decimal `json:"total_price" swaggertype:"number"`
+	DocTotalPrice   decimal.Decimal `json:"doc_total_price" swaggertype:"number"`
+	TaxRate         decimal.Decimal `json:"tax_rate" swaggertype:"number"`
+	NetAmount       decimal.Decimal `json:"net_amount" swaggertype:"number"`
+	TaxAmount       decimal.Decimal `json:"tax_amount" swaggertype:"number"`
+	GrossAmount     decimal.Decimal `json:"gross_amount" swaggertype:"number"`
+	Coming_Table_id string          `json:"coming_table_id"`
+	CreatedAt       string          `json:"created_at"`
+	UpdatedAt       string          `json:"updated_at"`
 }
 
 type ComingTableProductIdRequest struct {
 	Id string `json:"id"`
 }
 
+// UpdateComingTableProduct overwrites an arrival line. Cost is in the
+// currency of the coming table.
 type UpdateComingTableProduct struct {
-	ID              string  `json:"id"`
-	Category_id     string  `json:"category_id"`
-	Name            string  `json:"name"`
-	Price           float64 `json:"price"`
-	Barcode         string  `json:"barcode"`
-	Count           float64 `json:"count"`
-	TotalPrice      float64 `json:"total_price"`
-	Coming_Table_id string  `json:"coming_table_id"`
+	ID              string          `json:"id"`
+	Category_id     string          `json:"category_id" binding:"omitempty,uuid"`
+	Name            string          `json:"name" binding:"required,max=255"`
+	Price           decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	Cost            decimal.Decimal `json:"cost" binding:"omitempty,gt=0" swaggertype:"number"`
+	DocCost         decimal.Decimal `json:"-"`
+	Barcode         string          `json:"barcode" binding:"required,max=64"`
+	Count           decimal.Decimal `json:"count" binding:"gt=0" swaggertype:"number"`
+	TotalPrice      decimal.Decimal `json:"total_price" swaggertype:"number"`
+	DocTotalPrice   decimal.Decimal `json:"-"`
+	Coming_Table_id string          `json:"coming_table_id" binding:"required,uuid"`
 }
 
 type GetAllComingTableProductRequest struct {
-	Page        int    `json:"page"`
-	Limit       int    `json:"limit"`
-	Category_id string `json:"category_id"`
-	Barcode     string `json:"barcode"`
+	ListRequest
+	Coming_Table_id string           `json:"coming_table_id" form:"coming_table_id" binding:"omitempty,uuid"`
+	Category_id     string           `json:"category_id" form:"category_id" binding:"omitempty,uuid"`
+	Barcode         string           `json:"barcode" form:"barcode"`
+	Name            string           `json:"name" form:"name"`
+	PriceFrom       *decimal.Decimal `json:"price_from" form:"price_from" binding:"omitempty,gte=0" swaggertype:"number"`
+	PriceTo         *decimal.Decimal `json:"price_to" form:"price_to" binding:"omitempty,gte=0" swaggertype:"number"`
 }
 
 type GetAllComingTableProductResponse struct {
 	ComingTableProducts []ComingTableProduct `json:"coming_table_product"`
 	Count               int                  `json:"count"`
+	NextCursor          string               `json:"next_cursor,omitempty"`
 }
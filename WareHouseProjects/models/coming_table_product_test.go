@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestComingTableProductJSON(t *testing.T) {
+	body := `{"barcode":"1001","coming_table_id":"00000000-0000-0000-0000-000000000001","count":0.1,"cost":0.2}`
+
+	var req CreateComingTableProductSwagger
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Count.String() != "0.1" || req.Cost.String() != "0.2" {
+		t.Fatalf("decoded count %s, cost %s; want 0.1 and 0.2", req.Count, req.Cost)
+	}
+
+	line := ComingTableProduct{
+		Price:      decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2")),
+		Cost:       req.Cost,
+		Count:      req.Count,
+		TotalPrice: req.Cost.Mul(req.Count),
+		TaxRate:    decimal.RequireFromString("12"),
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"price":0.3,`, `"cost":0.2,`, `"count":0.1,`, `"total_price":0.02,`, `"tax_rate":12,`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s does not contain %s", data, want)
+		}
+	}
+}
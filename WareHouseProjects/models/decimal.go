@@ -0,0 +1,10 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Money and quantities are decimal.Decimal throughout the models so that they
+// add up exactly. They are still sent as JSON numbers, as clients are used to;
+// this is the one place that decides so, for every package using the models.
+func init() {
+	decimal.MarshalJSONWithoutQuotes = true
+}
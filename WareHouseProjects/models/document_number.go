@@ -0,0 +1,25 @@
+package models
+
+// DocumentType names a kind of document. Each kind is numbered on its own.
+type DocumentType string
+
+const (
+	DocumentComingTable DocumentType = "coming_table"
+)
+
+// NextDocumentNumber asks for the next number of a document of Type issued
+// by BranchID in Year.
+type NextDocumentNumber struct {
+	BranchID string
+	Type     DocumentType
+	Year     int
+}
+
+// DocumentNumber is a number taken for a document: the Number-th document of
+// its type issued by the branch with BranchCode in Year.
+type DocumentNumber struct {
+	BranchCode string
+	Type       DocumentType
+	Year       int
+	Number     int64
+}
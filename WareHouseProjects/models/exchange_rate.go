@@ -0,0 +1,51 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// CreateExchangeRate sets the rate of Currency from Date on: one unit of it
+// is worth Rate units of the base currency.
+type CreateExchangeRate struct {
+	Currency string          `json:"currency" binding:"required,len=3,uppercase"`
+	Rate     decimal.Decimal `json:"rate" binding:"gt=0" swaggertype:"number"`
+	Date     string          `json:"date" binding:"required,datetime=2006-01-02"`
+}
+
+type ExchangeRate struct {
+	ID        string          `json:"id"`
+	Currency  string          `json:"currency"`
+	Rate      decimal.Decimal `json:"rate" swaggertype:"number"`
+	Date      string          `json:"date"`
+	CreatedAt string          `json:"created_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+type ExchangeRateIdRequest struct {
+	Id string `json:"id"`
+}
+
+type UpdateExchangeRate struct {
+	ID       string          `json:"id"`
+	Currency string          `json:"currency" binding:"required,len=3,uppercase"`
+	Rate     decimal.Decimal `json:"rate" binding:"gt=0" swaggertype:"number"`
+	Date     string          `json:"date" binding:"required,datetime=2006-01-02"`
+}
+
+// ExchangeRateOn asks for the rate of Currency in force on Date, the latest
+// one set on or before it.
+type ExchangeRateOn struct {
+	Currency string
+	Date     string
+}
+
+type GetAllExchangeRateRequest struct {
+	ListRequest
+	Currency string `json:"currency" form:"currency" binding:"omitempty,len=3,uppercase"`
+	DateFrom string `json:"date_from" form:"date_from" binding:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" form:"date_to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type GetAllExchangeRateResponse struct {
+	ExchangeRates []ExchangeRate `json:"exchange_rate"`
+	Count         int            `json:"count"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
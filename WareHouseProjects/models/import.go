@@ -0,0 +1,74 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Import modes. A dry run validates and applies the file inside a transaction
+// that is rolled back, so its counts are exactly what a commit would do.
+const (
+	ImportDryRun = "dry_run"
+	ImportCommit = "commit"
+)
+
+type ImportRequest struct {
+	Mode string `json:"mode" form:"mode,default=dry_run" binding:"oneof=dry_run commit"`
+}
+
+// ImportRowError is a problem with one row of an imported file. Line is the
+// row's line in the file, the header being line 1.
+type ImportRowError struct {
+	Line   int    `json:"line"`
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type ProductImportResult struct {
+	Mode              string           `json:"mode"`
+	Rows              int              `json:"rows"`
+	Created           int              `json:"created"`
+	Updated           int              `json:"updated"`
+	CategoriesCreated int              `json:"categories_created"`
+	Errors            []ImportRowError `json:"errors"`
+}
+
+type ComingTableImportRequest struct {
+	ImportRequest
+	CreateUnknown bool `json:"create_unknown" form:"create_unknown"`
+}
+
+// ComingTableImportResult reconciles an invoice with the coming table it was
+// imported into. Lines are valued at cost in the base currency, the invoice
+// price where the row has one; InvoiceTotal sums the invoice's own prices in
+// the currency of the coming table, and PriceMismatches lists the rows whose
+// price, converted to the base currency, differs from the product's last
+// purchase cost.
+type ComingTableImportResult struct {
+	Mode            string           `json:"mode"`
+	Rows            int              `json:"rows"`
+	LinesCreated    int              `json:"lines_created"`
+	LinesUpdated    int              `json:"lines_updated"`
+	ProductsCreated int              `json:"products_created"`
+	TotalCount      decimal.Decimal  `json:"total_count" swaggertype:"number"`
+	TotalPrice      decimal.Decimal  `json:"total_price" swaggertype:"number"`
+	InvoiceTotal    decimal.Decimal  `json:"invoice_total" swaggertype:"number"`
+	Unknown         []UnknownBarcode `json:"unknown"`
+	PriceMismatches []PriceMismatch  `json:"price_mismatches"`
+	Errors          []ImportRowError `json:"errors"`
+}
+
+// UnknownBarcode is an invoice row whose barcode matches no product. It was
+// not imported.
+type UnknownBarcode struct {
+	Line    int    `json:"line"`
+	Barcode string `json:"barcode"`
+	Name    string `json:"name,omitempty"`
+}
+
+// PriceMismatch is an invoice row priced differently from what the product
+// last cost. Both prices are in the base currency, the invoice price converted
+// at the exchange rate of the coming table.
+type PriceMismatch struct {
+	Line         int             `json:"line"`
+	Barcode      string          `json:"barcode"`
+	InvoicePrice decimal.Decimal `json:"invoice_price" swaggertype:"number"`
+	LastCost     decimal.Decimal `json:"last_cost" swaggertype:"number"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// ListRequest holds the paging and ordering parameters shared by every list
+// endpoint. Sort is "field" or "field:asc|desc". Cursor is the next_cursor of
+// a previous page; when set, Page is ignored. Export, csv or xlsx, asks for
+// every matching row as a file instead of one page.
+type ListRequest struct {
+	Page   int    `json:"page" form:"page,default=1" binding:"min=1"`
+	Limit  int    `json:"limit" form:"limit" binding:"omitempty,min=1"`
+	Sort   string `json:"sort" form:"sort"`
+	Cursor string `json:"cursor" form:"cursor"`
+	Export string `json:"export" form:"export" binding:"omitempty,oneof=csv xlsx"`
+}
@@ -1,33 +1,58 @@
 package models
 
+import "github.com/shopspring/decimal"
+
 type CreateProduct struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Category_id string  `json:"category_id"`
+	Name        string          `json:"name" binding:"required,max=255"`
+	Price       decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	Barcode     string          `json:"barcode" binding:"required,max=64"`
+	Category_id string          `json:"category_id" binding:"omitempty,uuid"`
+	Unit_id     string          `json:"unit_id" binding:"omitempty,uuid"`
+	Tax_rate_id string          `json:"tax_rate_id" binding:"omitempty,uuid"`
 }
 
+// Product is a catalog entry. Price is the selling price; Cost is the
+// purchase cost of the last finished arrival and is empty, like the margin,
+// until the product was first received. Unit and Precision describe the unit
+// the product is counted in and are empty for a product without one. TaxRate
+// is the percent it is taxed at, its own or that of its category.
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Category_id string  `json:"category_id"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID            string           `json:"id"`
+	Name          string           `json:"name"`
+	Price         decimal.Decimal  `json:"price" swaggertype:"number"`
+	Cost          *decimal.Decimal `json:"cost" swaggertype:"number"`
+	Margin        *decimal.Decimal `json:"margin" swaggertype:"number"`
+	MarginPercent *decimal.Decimal `json:"margin_percent" swaggertype:"number"`
+	Barcode       string           `json:"barcode"`
+	Category_id   string           `json:"category_id"`
+	Unit_id       string           `json:"unit_id"`
+	Unit          string           `json:"unit"`
+	Precision     *int             `json:"precision"`
+	Tax_rate_id   string           `json:"tax_rate_id"`
+	TaxRate       decimal.Decimal  `json:"tax_rate" swaggertype:"number"`
+	CreatedAt     string           `json:"created_at"`
+	UpdatedAt     string           `json:"updated_at"`
 }
 type UpdateProduct struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Category_id string  `json:"category_id"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name" binding:"required,max=255"`
+	Price       decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	Barcode     string          `json:"barcode" binding:"required,max=64"`
+	Category_id string          `json:"category_id" binding:"omitempty,uuid"`
+	Unit_id     string          `json:"unit_id" binding:"omitempty,uuid"`
+	Tax_rate_id string          `json:"tax_rate_id" binding:"omitempty,uuid"`
 }
 
 type RespBarcodeProduct struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Category_id string  `json:"category_id"`
+	Name        string           `json:"name"`
+	Price       decimal.Decimal  `json:"price" swaggertype:"number"`
+	Cost        *decimal.Decimal `json:"cost" swaggertype:"number"`
+	Category_id string           `json:"category_id"`
+	Unit_id     string           `json:"unit_id"`
+	Unit        string           `json:"unit"`
+	Precision   *int             `json:"precision"`
+	Tax_rate_id string           `json:"tax_rate_id"`
+	TaxRate     decimal.Decimal  `json:"tax_rate" swaggertype:"number"`
 }
 
 type ProductIdRequest struct {
@@ -35,13 +60,42 @@ type ProductIdRequest struct {
 }
 
 type GetAllProductRequest struct {
-	Page    int    `json:"page"`
-	Limit   int    `json:"limit"`
-	Name    string `json:"name"`
-	Barcode string `json:"barcode"`
+	ListRequest
+	Name        string           `json:"name" form:"name"`
+	Barcode     string           `json:"barcode" form:"barcode"`
+	Category_id string           `json:"category_id" form:"category_id" binding:"omitempty,uuid"`
+	Unit_id     string           `json:"unit_id" form:"unit_id" binding:"omitempty,uuid"`
+	PriceFrom   *decimal.Decimal `json:"price_from" form:"price_from" binding:"omitempty,gte=0" swaggertype:"number"`
+	PriceTo     *decimal.Decimal `json:"price_to" form:"price_to" binding:"omitempty,gte=0" swaggertype:"number"`
 }
 
 type GetAllProductResponse struct {
-	Products []Product `json:"product"`
-	Count    int       `json:"count"`
+	Products   []Product `json:"product"`
+	Count      int       `json:"count"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type SearchProductRequest struct {
+	Query       string `json:"q" form:"q" binding:"required,max=100"`
+	Category_id string `json:"category_id" form:"category_id" binding:"omitempty,uuid"`
+	Limit       int    `json:"limit" form:"limit" binding:"omitempty,min=1"`
+}
+
+// ProductSearchResult is a product matching a search, best matches first.
+// Highlight marks the matched words of the name and category name with
+// <b></b>.
+type ProductSearchResult struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Price        decimal.Decimal `json:"price" swaggertype:"number"`
+	Barcode      string          `json:"barcode"`
+	Category_id  string          `json:"category_id"`
+	CategoryName string          `json:"category_name"`
+	Rank         float64         `json:"rank"`
+	Highlight    SearchHighlight `json:"highlight"`
+}
+
+type SearchHighlight struct {
+	Name         string `json:"name"`
+	CategoryName string `json:"category_name,omitempty"`
 }
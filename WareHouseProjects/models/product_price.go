@@ -0,0 +1,52 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+type PriceStatus string
+
+const (
+	// PriceScheduled is a price change waiting for its effective time.
+	PriceScheduled PriceStatus = "scheduled"
+	// PriceApplied is a price change that has become the product price.
+	PriceApplied PriceStatus = "applied"
+)
+
+// CreateProductPrice changes the price of a product at EffectiveAt, or right
+// away when EffectiveAt is empty or already past.
+type CreateProductPrice struct {
+	ProductID   string          `json:"product_id"`
+	Price       decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	EffectiveAt string          `json:"effective_at" binding:"omitempty,datetime=2006-01-02 15:04:05"`
+}
+
+// ProductPrice is one entry of the price history of a product. OldPrice is
+// the price it replaced and is empty until the change is applied.
+type ProductPrice struct {
+	ID          string           `json:"id"`
+	ProductID   string           `json:"product_id"`
+	ProductName string           `json:"product_name"`
+	OldPrice    *decimal.Decimal `json:"old_price" swaggertype:"number"`
+	Price       decimal.Decimal  `json:"price" swaggertype:"number"`
+	Status      PriceStatus      `json:"status"`
+	EffectiveAt string           `json:"effective_at"`
+	AppliedAt   string           `json:"applied_at"`
+	CreatedAt   string           `json:"created_at"`
+}
+
+type ProductPriceIdRequest struct {
+	Id string `json:"id"`
+}
+
+type GetAllProductPriceRequest struct {
+	ListRequest
+	ProductID string      `json:"product_id" form:"product_id" binding:"omitempty,uuid"`
+	Status    PriceStatus `json:"status" form:"status" binding:"omitempty,oneof=scheduled applied"`
+	DateFrom  string      `json:"date_from" form:"date_from" binding:"omitempty,datetime=2006-01-02"`
+	DateTo    string      `json:"date_to" form:"date_to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type GetAllProductPriceResponse struct {
+	ProductPrices []ProductPrice `json:"product_price"`
+	Count         int            `json:"count"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
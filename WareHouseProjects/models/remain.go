@@ -1,31 +1,39 @@
 package models
 
-type CreateRemain struct {
-	Branch_id   string  `json:"branch_id"`
-	Category_id string  `json:"category_id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Count       float64 `json:"count"`
-	TotalPrice  float64 `json:"total_price"`
-}
+import "github.com/shopspring/decimal"
 
-type CheckRemain struct {
-	Barcode   string `json:"barcode"`
-	Branch_id string `json:"branch_id"`
+type CreateRemain struct {
+	Branch_id   string          `json:"branch_id" binding:"required,uuid"`
+	Category_id string          `json:"category_id" binding:"omitempty,uuid"`
+	Name        string          `json:"name" binding:"required,max=255"`
+	Price       decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	Cost        decimal.Decimal `json:"cost" binding:"gte=0" swaggertype:"number"`
+	Barcode     string          `json:"barcode" binding:"required,max=64"`
+	Count       decimal.Decimal `json:"count" binding:"gte=0" swaggertype:"number"`
+	TotalPrice  decimal.Decimal `json:"total_price" swaggertype:"number"`
 }
 
+// Remain is the stock of a product in a branch. Price is the current selling
+// price, Cost the average purchase cost of the units in stock and TotalPrice
+// the stock valued at cost. Margin is per unit, TotalMargin for the whole
+// stock. Unit and Precision are those of the product.
 type Remain struct {
-	ID          string  `json:"id"`
-	Branch_id   string  `json:"branch_id"`
-	Category_id string  `json:"category_id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Count       float64 `json:"count"`
-	TotalPrice  float64 `json:"total_price"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID            string          `json:"id"`
+	Branch_id     string          `json:"branch_id"`
+	Category_id   string          `json:"category_id"`
+	Name          string          `json:"name"`
+	Price         decimal.Decimal `json:"price" swaggertype:"number"`
+	Cost          decimal.Decimal `json:"cost" swaggertype:"number"`
+	Margin        decimal.Decimal `json:"margin" swaggertype:"number"`
+	MarginPercent decimal.Decimal `json:"margin_percent" swaggertype:"number"`
+	Barcode       string          `json:"barcode"`
+	Count         decimal.Decimal `json:"count" swaggertype:"number"`
+	Unit          string          `json:"unit"`
+	Precision     *int            `json:"precision"`
+	TotalPrice    decimal.Decimal `json:"total_price" swaggertype:"number"`
+	TotalMargin   decimal.Decimal `json:"total_margin" swaggertype:"number"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
 }
 
 type RemainIdRequest struct {
@@ -33,25 +41,30 @@ type RemainIdRequest struct {
 }
 
 type UpdateRemain struct {
-	ID          string  `json:"id"`
-	Branch_id   string  `json:"branch_id"`
-	Category_id string  `json:"category_id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Barcode     string  `json:"barcode"`
-	Count       float64 `json:"count"`
-	TotalPrice  float64 `json:"total_price"`
+	ID          string          `json:"id"`
+	Branch_id   string          `json:"branch_id" binding:"required,uuid"`
+	Category_id string          `json:"category_id" binding:"omitempty,uuid"`
+	Name        string          `json:"name" binding:"required,max=255"`
+	Price       decimal.Decimal `json:"price" binding:"gt=0" swaggertype:"number"`
+	Cost        decimal.Decimal `json:"cost" binding:"omitempty,gt=0" swaggertype:"number"`
+	Barcode     string          `json:"barcode" binding:"required,max=64"`
+	Count       decimal.Decimal `json:"count" binding:"gte=0" swaggertype:"number"`
+	TotalPrice  decimal.Decimal `json:"total_price" swaggertype:"number"`
 }
 
 type GetAllRemainRequest struct {
-	Page        int    `json:"page"`
-	Limit       int    `json:"limit"`
-	Branch_id   string `json:"branch_id"`
-	Category_id string `json:"category_id"`
-	Barcode     string `json:"barcode"`
+	ListRequest
+	Branch_id   string           `json:"branch_id" form:"branch_id" binding:"omitempty,uuid"`
+	Category_id string           `json:"category_id" form:"category_id" binding:"omitempty,uuid"`
+	Product_id  string           `json:"product_id" form:"product_id" binding:"omitempty,uuid"`
+	Barcode     string           `json:"barcode" form:"barcode"`
+	Name        string           `json:"name" form:"name"`
+	PriceFrom   *decimal.Decimal `json:"price_from" form:"price_from" binding:"omitempty,gte=0" swaggertype:"number"`
+	PriceTo     *decimal.Decimal `json:"price_to" form:"price_to" binding:"omitempty,gte=0" swaggertype:"number"`
 }
 
 type GetAllRemainResponse struct {
 	Remainings []Remain `json:"remaining"`
 	Count      int      `json:"count"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,47 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+type CreateTaxRate struct {
+	Name string          `json:"name" binding:"required,max=255"`
+	Rate decimal.Decimal `json:"rate" binding:"gte=0,lte=100" swaggertype:"number"`
+}
+
+// TaxRate is a tax rate in percent products and categories are taxed at.
+type TaxRate struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Rate      decimal.Decimal `json:"rate" swaggertype:"number"`
+	CreatedAt string          `json:"created_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+type UpdateTaxRate struct {
+	ID   string          `json:"id"`
+	Name string          `json:"name" binding:"required,max=255"`
+	Rate decimal.Decimal `json:"rate" binding:"gte=0,lte=100" swaggertype:"number"`
+}
+
+type TaxRateIdRequest struct {
+	Id string `json:"id"`
+}
+
+type GetAllTaxRateRequest struct {
+	ListRequest
+	Name string `json:"name" form:"name"`
+}
+
+type GetAllTaxRateResponse struct {
+	TaxRates   []TaxRate `json:"tax_rate"`
+	Count      int       `json:"count"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ComingTableTax totals the lines of a coming table taxed at TaxRate, in the
+// base currency.
+type ComingTableTax struct {
+	TaxRate     decimal.Decimal `json:"tax_rate" swaggertype:"number"`
+	NetAmount   decimal.Decimal `json:"net_amount" swaggertype:"number"`
+	TaxAmount   decimal.Decimal `json:"tax_amount" swaggertype:"number"`
+	GrossAmount decimal.Decimal `json:"gross_amount" swaggertype:"number"`
+}
@@ -0,0 +1,40 @@
+package models
+
+type CreateUnit struct {
+	Name      string `json:"name" binding:"required,max=255"`
+	ShortName string `json:"short_name" binding:"required,max=16"`
+	Precision int    `json:"precision" binding:"gte=0,lte=6"`
+}
+
+// Unit is a unit of measure products are counted in. Precision is the number
+// of decimals a count may have; 0 allows whole units only.
+type Unit struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ShortName string `json:"short_name"`
+	Precision int    `json:"precision"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type UnitIdRequest struct {
+	Id string `json:"id"`
+}
+
+type UpdateUnit struct {
+	ID        string `json:"id"`
+	Name      string `json:"name" binding:"required,max=255"`
+	ShortName string `json:"short_name" binding:"required,max=16"`
+	Precision int    `json:"precision" binding:"gte=0,lte=6"`
+}
+
+type GetAllUnitRequest struct {
+	ListRequest
+	Name string `json:"name" form:"name"`
+}
+
+type GetAllUnitResponse struct {
+	Units      []Unit `json:"unit"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// Role decides what a user may do; config.Config.Policy lists the methods
+// each role may call on each object.
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleManager Role = "manager"
+	RoleClerk   Role = "clerk"
+	RoleAuditor Role = "auditor"
+)
+
+// CreateUser adds a user. PasswordHash is filled by the server from Password.
+// A user of a branch only sees the coming tables and stock of that branch; a
+// clerk must have one.
+type CreateUser struct {
+	Username     string `json:"username" binding:"required,min=3,max=64"`
+	Password     string `json:"password" binding:"required,min=8,max=72"`
+	PasswordHash string `json:"-"`
+	Role         Role   `json:"role" binding:"required,oneof=admin manager clerk auditor"`
+	Branch_id    string `json:"branch_id" binding:"required_if=Role clerk,omitempty,uuid"`
+}
+
+type User struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Role      Role   `json:"role"`
+	Branch_id string `json:"branch_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type UserIdRequest struct {
+	Id string `json:"id"`
+}
+
+// UpdateUser overwrites a user. Without a Password the user keeps the
+// current one.
+type UpdateUser struct {
+	ID           string `json:"id"`
+	Username     string `json:"username" binding:"required,min=3,max=64"`
+	Password     string `json:"password" binding:"omitempty,min=8,max=72"`
+	PasswordHash string `json:"-"`
+	Role         Role   `json:"role" binding:"required,oneof=admin manager clerk auditor"`
+	Branch_id    string `json:"branch_id" binding:"required_if=Role clerk,omitempty,uuid"`
+}
+
+type GetAllUserRequest struct {
+	ListRequest
+	Username  string `json:"username" form:"username"`
+	Role      Role   `json:"role" form:"role" binding:"omitempty,oneof=admin manager clerk auditor"`
+	Branch_id string `json:"branch_id" form:"branch_id" binding:"omitempty,uuid"`
+}
+
+type GetAllUserResponse struct {
+	Users      []User `json:"user"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UserCredentials is what a user signs in with.
+type UserCredentials struct {
+	ID           string
+	PasswordHash string
+}
@@ -0,0 +1,109 @@
+// Package auth hashes passwords and API keys and issues and checks the JWTs
+// users authenticate with.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Token types. An access token authenticates requests; a refresh token can
+// only be traded for a new pair of tokens, once.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
+// apiKeyPrefix starts every API key, so that a leaked one is recognisable.
+const apiKeyPrefix = "whk_"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// DummyHash is a bcrypt hash at the cost HashPassword uses. A login for an
+// unknown username checks its password against it, which takes as long as
+// checking a real one, so the time of a failed login does not tell whether
+// the username exists.
+const DummyHash = "$2a$10$C0MutJo6l9arHf9htW/zB.tw.z2D1jo8sc5zSxYOCjtpEh7a.HM1G"
+
+// Claims are the claims of the tokens issued here. Subject is the user id
+// and ID identifies a refresh token in storage.
+type Claims struct {
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken signs a token of typ for userID that expires after ttl.
+func NewToken(secret []byte, typ, userID, id string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Type: typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// ParseToken checks the signature, expiry and type of token and returns its
+// claims. Every failure is ErrInvalidToken.
+func ParseToken(secret []byte, typ, token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Type != typ || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: expected %s token", ErrInvalidToken, typ)
+	}
+	return &claims, nil
+}
+
+// NewAPIKey returns a random API key and the prefix it is shown by once it
+// can no longer be read.
+func NewAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(apiKeyPrefix)+6], nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys
+// are random enough that a fast hash is safe.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	return secret, err
+}
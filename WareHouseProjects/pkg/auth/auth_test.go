@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDummyHash(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(DummyHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("cost = %d, want %d as HashPassword uses", cost, bcrypt.DefaultCost)
+	}
+	if CheckPassword(DummyHash, "") {
+		t.Error("empty password matches")
+	}
+}
@@ -0,0 +1,13 @@
+// Package fonts embeds the DejaVu Sans fonts used to render PDF documents.
+// Unlike the PDF core fonts they cover Cyrillic and Latin extended, so product
+// and branch names print as entered. See LICENSE for the font license.
+package fonts
+
+import _ "embed"
+
+var (
+	//go:embed DejaVuSans.ttf
+	Regular []byte
+	//go:embed DejaVuSans-Bold.ttf
+	Bold []byte
+)
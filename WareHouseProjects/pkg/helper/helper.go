@@ -8,24 +8,6 @@ import (
 	"strings"
 )
 
-func ReplaceQueryParams(namedQuery string, params map[string]interface{}) (string, []interface{}) {
-	var (
-		i    int = 1
-		args []interface{}
-	)
-
-	for k, v := range params {
-		if k != "" {
-			namedQuery = strings.ReplaceAll(namedQuery, ":"+k, "$"+strconv.Itoa(i))
-
-			args = append(args, v)
-			i++
-		}
-	}
-
-	return namedQuery, args
-}
-
 func ReplaceSQL(old, searchPattern string) string {
 	tmpCount := strings.Count(old, searchPattern)
 	for m := 1; m <= tmpCount; m++ {
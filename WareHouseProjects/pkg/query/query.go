@@ -0,0 +1,109 @@
+// Package query composes SELECT statements with positional arguments.
+//
+// Conditions are written with ? placeholders which are numbered $1, $2, ...
+// in the order they are added, so a condition can never pick up the argument
+// of another one:
+//
+//	q := query.Select(`SELECT "id", "name" FROM "product"`)
+//	q.Where(`"name" ILIKE '%' || ? || '%'`, name)
+//	q.Where(`"price" BETWEEN ? AND ?`, from, to)
+//	q.OrderBy(`"created_at" DESC`).Limit(10).Offset(20)
+//	sql, args := q.Build()
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Builder accumulates the parts of one SELECT. The zero value is not usable,
+// start with Select.
+type Builder struct {
+	base    string
+	where   []string
+	args    []interface{}
+	orderBy []string
+	limit   *int
+	offset  *int
+}
+
+// Select starts a query from base, everything up to and including FROM and
+// any joins. Placeholders in base are bound to args like in Where.
+func Select(base string, args ...interface{}) *Builder {
+	b := &Builder{}
+	b.base = b.bind(strings.TrimSpace(base), args)
+	return b
+}
+
+// Where adds a condition joined to the others with AND.
+func (b *Builder) Where(cond string, args ...interface{}) *Builder {
+	b.where = append(b.where, "("+b.bind(cond, args)+")")
+	return b
+}
+
+// OrderBy appends ordering terms such as `"price" DESC`. Terms are SQL, never
+// pass user input here without checking it against a whitelist.
+func (b *Builder) OrderBy(terms ...string) *Builder {
+	b.orderBy = append(b.orderBy, terms...)
+	return b
+}
+
+// Limit sets the LIMIT of the query.
+func (b *Builder) Limit(n int) *Builder {
+	b.limit = &n
+	return b
+}
+
+// Offset sets the OFFSET of the query.
+func (b *Builder) Offset(n int) *Builder {
+	b.offset = &n
+	return b
+}
+
+// Build returns the SQL and its arguments in placeholder order.
+func (b *Builder) Build() (string, []interface{}) {
+	var sql strings.Builder
+	sql.WriteString(b.base)
+
+	if len(b.where) > 0 {
+		sql.WriteString(" WHERE ")
+		sql.WriteString(strings.Join(b.where, " AND "))
+	}
+	if len(b.orderBy) > 0 {
+		sql.WriteString(" ORDER BY ")
+		sql.WriteString(strings.Join(b.orderBy, ", "))
+	}
+
+	args := append([]interface{}(nil), b.args...)
+	if b.limit != nil {
+		args = append(args, *b.limit)
+		sql.WriteString(" LIMIT $" + strconv.Itoa(len(args)))
+	}
+	if b.offset != nil {
+		args = append(args, *b.offset)
+		sql.WriteString(" OFFSET $" + strconv.Itoa(len(args)))
+	}
+
+	return sql.String(), args
+}
+
+// bind numbers the placeholders of cond after the arguments already bound
+// and records args. cond must contain exactly one ? per argument; a mismatch
+// is a programming error and panics.
+func (b *Builder) bind(cond string, args []interface{}) string {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		panic(fmt.Sprintf("query: %q has %d placeholders but %d arguments", cond, n, len(args)))
+	}
+
+	var out strings.Builder
+	for _, arg := range args {
+		i := strings.IndexByte(cond, '?')
+		out.WriteString(cond[:i])
+		b.args = append(b.args, arg)
+		out.WriteString("$" + strconv.Itoa(len(b.args)))
+		cond = cond[i+1:]
+	}
+	out.WriteString(cond)
+	return out.String()
+}
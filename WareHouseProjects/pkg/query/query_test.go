@@ -0,0 +1,182 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func() *Builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name: "base only",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "product"`)
+			},
+			wantSQL:  `SELECT "id" FROM "product"`,
+			wantArgs: []interface{}{},
+		},
+		{
+			name: "base is trimmed",
+			build: func() *Builder {
+				return Select(`
+					SELECT "id" FROM "product"
+				`)
+			},
+			wantSQL:  `SELECT "id" FROM "product"`,
+			wantArgs: []interface{}{},
+		},
+		{
+			name: "select args come first",
+			build: func() *Builder {
+				return Select(`SELECT "id", ? AS "branch" FROM "product" p JOIN "branch_price" bp ON bp."branch_id" = ?`, "b1", "b2").
+					Where(`"name" = ?`, "milk")
+			},
+			wantSQL:  `SELECT "id", $1 AS "branch" FROM "product" p JOIN "branch_price" bp ON bp."branch_id" = $2 WHERE ("name" = $3)`,
+			wantArgs: []interface{}{"b1", "b2", "milk"},
+		},
+		{
+			name: "where conditions are numbered in order",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "product"`).
+					Where(`"name" ILIKE '%' || ? || '%'`, "milk").
+					Where(`"price" BETWEEN ? AND ?`, 1, 2).
+					Where(`"deleted_at" IS NULL`)
+			},
+			wantSQL:  `SELECT "id" FROM "product" WHERE ("name" ILIKE '%' || $1 || '%') AND ("price" BETWEEN $2 AND $3) AND ("deleted_at" IS NULL)`,
+			wantArgs: []interface{}{"milk", 1, 2},
+		},
+		{
+			name: "limit and offset follow the where args",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "product"`).
+					Where(`"barcode" = ?`, "123").
+					Limit(10).
+					Offset(20)
+			},
+			wantSQL:  `SELECT "id" FROM "product" WHERE ("barcode" = $1) LIMIT $2 OFFSET $3`,
+			wantArgs: []interface{}{"123", 10, 20},
+		},
+		{
+			name: "offset without limit",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "product"`).Offset(5)
+			},
+			wantSQL:  `SELECT "id" FROM "product" OFFSET $1`,
+			wantArgs: []interface{}{5},
+		},
+		{
+			name: "clauses are ordered whatever the call order",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "product"`).
+					Offset(20).
+					Limit(10).
+					OrderBy(`"created_at" DESC`).
+					Where(`"name" = ?`, "milk").
+					OrderBy(`"id" DESC`).
+					Where(`"price" > ?`, 3)
+			},
+			wantSQL:  `SELECT "id" FROM "product" WHERE ("name" = $1) AND ("price" > $2) ORDER BY "created_at" DESC, "id" DESC LIMIT $3 OFFSET $4`,
+			wantArgs: []interface{}{"milk", 3, 10, 20},
+		},
+		{
+			name: "casts next to placeholders",
+			build: func() *Builder {
+				return Select(`SELECT "id" FROM "audit_log"`).
+					Where(`"created_at" >= ?::timestamptz`, "2024-01-01").
+					Where(`(?::uuid IS NULL OR "branch_id" = ?)`, nil, nil)
+			},
+			wantSQL:  `SELECT "id" FROM "audit_log" WHERE ("created_at" >= $1::timestamptz) AND (($2::uuid IS NULL OR "branch_id" = $3))`,
+			wantArgs: []interface{}{"2024-01-01", nil, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.build().Build()
+			if sql != tt.wantSQL {
+				t.Errorf("sql\n got: %s\nwant: %s", sql, tt.wantSQL)
+			}
+			if args == nil {
+				args = []interface{}{}
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildTwice(t *testing.T) {
+	q := Select(`SELECT "id" FROM "product"`).Where(`"name" = ?`, "milk").Limit(10)
+
+	sql1, args1 := q.Build()
+	sql2, args2 := q.Build()
+	if sql1 != sql2 || !reflect.DeepEqual(args1, args2) {
+		t.Fatalf("second Build differs: %q %v, first %q %v", sql2, args2, sql1, args1)
+	}
+
+	// LIMIT must not leak into the builder's own args.
+	q.Where(`"price" > ?`, 1)
+	sql, args := q.Build()
+	want := `SELECT "id" FROM "product" WHERE ("name" = $1) AND ("price" > $2) LIMIT $3`
+	if sql != want {
+		t.Errorf("sql\n got: %s\nwant: %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"milk", 1, 10}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestBindMismatchPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func()
+	}{
+		{
+			name:  "where with too few args",
+			build: func() { Select(`SELECT 1`).Where(`"a" = ? AND "b" = ?`, 1) },
+		},
+		{
+			name:  "where with too many args",
+			build: func() { Select(`SELECT 1`).Where(`"a" = ?`, 1, 2) },
+		},
+		{
+			name:  "where without placeholder but with an arg",
+			build: func() { Select(`SELECT 1`).Where(`"a" IS NULL`, 1) },
+		},
+		{
+			name:  "select with too few args",
+			build: func() { Select(`SELECT ? FROM "product" WHERE "id" = ?`, 1) },
+		},
+		{
+			name:  "select with too many args",
+			build: func() { Select(`SELECT "id" FROM "product"`, 1) },
+		},
+		{
+			// A literal ? is counted as a placeholder too, so it cannot be
+			// written into a condition; bind it as an argument instead.
+			name:  "literal question mark in a string",
+			build: func() { Select(`SELECT 1`).Where(`"name" = '?'`) },
+		},
+		{
+			name:  "literal question mark next to a placeholder",
+			build: func() { Select(`SELECT 1`).Where(`"name" = '?' OR "name" = ?`, "milk") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.build()
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package sheet reads and writes tabular files, CSV or XLSX. When reading,
+// the first row is the header and columns are looked up by header name, so
+// files may order them freely and carry extra columns.
+package sheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ErrFormat is returned for files that are neither CSV nor XLSX.
+var ErrFormat = errors.New("unsupported file format, use .csv or .xlsx")
+
+// FormatOf picks the format from the extension of filename.
+func FormatOf(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	return "", ErrFormat
+}
+
+// Table is a file read by Read.
+type Table struct {
+	columns map[string]int
+	// Rows are the non-blank data rows, without the header.
+	Rows []Row
+}
+
+// Row is one data row. Line is its 1-based line in the file, as a
+// spreadsheet shows it.
+type Row struct {
+	Line  int
+	Cells []string
+}
+
+// Read reads a CSV file or the first sheet of an XLSX file.
+func Read(format Format, r io.Reader) (*Table, error) {
+	var (
+		rows []Row
+		err  error
+	)
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case XLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, ErrFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	t := &Table{columns: make(map[string]int, len(rows[0].Cells))}
+	for i, name := range rows[0].Cells {
+		t.columns[normalize(name)] = i
+	}
+	for _, row := range rows[1:] {
+		if !blank(row.Cells) {
+			t.Rows = append(t.Rows, row)
+		}
+	}
+	return t, nil
+}
+
+// Has reports whether the header has column.
+func (t *Table) Has(column string) bool {
+	_, ok := t.columns[normalize(column)]
+	return ok
+}
+
+// Value returns the trimmed cell of row in column, "" if the row is short or
+// the column is missing.
+func (t *Table) Value(row Row, column string) string {
+	i, ok := t.columns[normalize(column)]
+	if !ok || i >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[i])
+}
+
+func readCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []Row
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, Row{Line: line, Cells: cells})
+	}
+}
+
+func readXLSX(r io.Reader) ([]Row, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading xlsx: %w", err)
+	}
+	defer f.Close()
+
+	cells, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, fmt.Errorf("reading xlsx: %w", err)
+	}
+
+	rows := make([]Row, 0, len(cells))
+	for i, row := range cells {
+		rows = append(rows, Row{Line: i + 1, Cells: row})
+	}
+	return rows, nil
+}
+
+func normalize(column string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+}
+
+func blank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+// Writer writes rows to a CSV or XLSX file. CSV rows go to the underlying
+// writer as they come; an XLSX file is written out by Close. Decimals are
+// written to CSV with their exact digits and to XLSX as number cells, which
+// spreadsheets hold as floats.
+type Writer interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a Writer of format writing to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case XLSX:
+		f := excelize.NewFile()
+		stream, err := f.NewStreamWriter(f.GetSheetName(0))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &xlsxWriter{f: f, stream: stream, w: w}, nil
+	}
+	return nil, ErrFormat
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format Format) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case decimal.Decimal:
+			record[i] = v.String()
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxWriter struct {
+	f      *excelize.File
+	stream *excelize.StreamWriter
+	w      io.Writer
+	rows   int
+}
+
+func (x *xlsxWriter) Write(row []interface{}) error {
+	x.rows++
+	cell, err := excelize.CoordinatesToCellName(1, x.rows)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		if d, ok := v.(decimal.Decimal); ok {
+			v = d.InexactFloat64()
+		}
+		values[i] = v
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.f.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.f.Write(x.w)
+}
@@ -0,0 +1,66 @@
+package sheet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+var testRows = [][]interface{}{
+	{"name", "price", "count"},
+	{"Milk", decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2")), int64(3)},
+	{"Bread", decimal.RequireFromString("12345678901234567.89"), int64(1)},
+}
+
+func write(t *testing.T, format Format) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range testRows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteCSVDecimals(t *testing.T) {
+	got := string(write(t, CSV))
+	want := "name,price,count\nMilk,0.3,3\nBread,12345678901234567.89,1\n"
+	if got != want {
+		t.Errorf("csv\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestWriteXLSXDecimals(t *testing.T) {
+	f, err := excelize.OpenReader(bytes.NewReader(write(t, XLSX)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+
+	if v, _ := f.GetCellValue(sheet, "B2"); v != "0.3" {
+		t.Errorf("B2 = %q, want 0.3", v)
+	}
+	// Prices are numbers a spreadsheet can compute with, not text.
+	if err := f.SetCellFormula(sheet, "D1", "SUM(B2,B2)"); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := f.CalcCellValue(sheet, "D1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != "0.6" {
+		t.Errorf("SUM(B2,B2) = %s, want 0.6", sum)
+	}
+}
@@ -0,0 +1,17 @@
+package storage
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor notes userID as the user on whose behalf ctx changes data, for
+// the audit log.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// Actor returns the user ctx changes data for, or "" for the server itself.
+func Actor(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
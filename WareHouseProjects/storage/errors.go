@@ -0,0 +1,45 @@
+package storage
+
+import "errors"
+
+// Error kinds returned by StorageI implementations. Check them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrInvalidState = errors.New("invalid state")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Error is a domain error of a given Kind. Message is safe to return to the
+// client, Err is the underlying cause and is meant for logs only. Field names
+// the input field at fault, when the error is about one.
+type Error struct {
+	Kind    error
+	Field   string
+	Message string
+	Err     error
+}
+
+func NewError(kind error, message string, cause error) error {
+	return &Error{Kind: kind, Message: message, Err: cause}
+}
+
+// NewFieldError is NewError for an error about the input field.
+func NewFieldError(kind error, field, message string, cause error) error {
+	return &Error{Kind: kind, Field: field, Message: message, Err: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeySortColumns are the fields the list may be sorted by.
+var apiKeySortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const apiKeyColumns = `
+	"id",
+	"name",
+	"user_id",
+	"prefix",
+	"last_used_at",
+	"created_at"`
+
+type apiKeyRepo struct {
+	db dbtx
+}
+
+func NewApiKeyRepo(db dbtx) *apiKeyRepo {
+	return &apiKeyRepo{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepo) CreateApiKey(ctx context.Context, req *models.CreateApiKey) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "api_key", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "api_key"(
+			"id",
+			"user_id",
+			"name",
+			"prefix",
+			"key_hash",
+			"created_at")
+		VALUES ($1, $2, $3, $4, $5, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.User_id,
+		req.Name,
+		req.Prefix,
+		req.KeyHash,
+	)
+	if err != nil {
+		return "", wrapError(err, "api key")
+	}
+
+	return id, nil
+}
+
+func (r *apiKeyRepo) GetApiKey(ctx context.Context, req *models.ApiKeyIdRequest) (*models.ApiKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM "api_key" WHERE "id" = $1`
+
+	key, err := scanApiKey(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "api key")
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepo) GetAllApiKey(ctx context.Context, req *models.GetAllApiKeyRequest) (*models.GetAllApiKeyResponse, error) {
+	page, err := newListPage(req.ListRequest, apiKeySortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllApiKeyResponse{}
+
+	resp.ApiKeys = make([]models.ApiKey, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + apiKeyColumns + `
+			FROM "api_key"
+		`)
+	if req.User_id != "" {
+		q.Where(`"user_id" = ?`, req.User_id)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "api key")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		key, err := scanApiKey(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, key.ID) {
+			break
+		}
+		resp.ApiKeys = append(resp.ApiKeys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "api key")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// DeleteApiKey revokes the API key.
+func (r *apiKeyRepo) DeleteApiKey(ctx context.Context, req *models.ApiKeyIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "api_key", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "api_key" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "api key")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("api key")
+	}
+
+	return req.Id, nil
+}
+
+// GetApiKeyUser returns the user the API key with keyHash acts as and notes
+// that the key was used.
+func (r *apiKeyRepo) GetApiKeyUser(ctx context.Context, keyHash string) (string, error) {
+	query := `
+		UPDATE "api_key"
+		SET "last_used_at" = NOW()
+		WHERE "key_hash" = $1
+		RETURNING "user_id"`
+
+	var userID string
+	if err := r.db.QueryRow(ctx, query, keyHash).Scan(&userID); err != nil {
+		return "", wrapError(err, "api key")
+	}
+
+	return userID, nil
+}
+
+// scanApiKey scans apiKeyColumns, after the leading columns of a list query
+// when lead is given.
+func scanApiKey(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.ApiKey, error) {
+	var (
+		key        models.ApiKey
+		lastUsedAt sql.NullTime
+		createdAt  time.Time
+	)
+	err := row.Scan(append(lead,
+		&key.ID,
+		&key.Name,
+		&key.User_id,
+		&key.Prefix,
+		&lastUsedAt,
+		&createdAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = lastUsedAt.Time.Format(time.RFC3339)
+	}
+	key.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &key, nil
+}
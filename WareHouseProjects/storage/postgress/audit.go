@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// auditTables are the tables of the audited entities.
+var auditTables = map[string]string{
+	"branch":               "branches",
+	"branch_price":         "branch_price",
+	"category":             "category",
+	"exchange_rate":        "exchange_rate",
+	"unit":                 "unit",
+	"tax_rate":             "tax_rate",
+	"product":              "product",
+	"product_price":        "product_price",
+	"coming_table":         "coming_table",
+	"coming_table_product": "coming_table_product",
+	"remain":               "remaining",
+	"user":                 "users",
+	"api_key":              "api_key",
+}
+
+// auditSortColumns are the fields the list may be sorted by.
+var auditSortColumns = map[string]sortColumn{
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+// auditSource replaces the "audit_log" table in reads, adding the username of
+// the actor as "actor".
+const auditSource = `(
+	SELECT
+		a.*,
+		u."username" AS "actor"
+	FROM "audit_log" a
+	LEFT JOIN "users" u ON u."id" = a."actor_id"
+) "audit_log"`
+
+const auditColumns = `
+	"id",
+	"actor_id",
+	"actor",
+	"entity_type",
+	"entity_id",
+	"action",
+	"before",
+	"after",
+	"created_at"`
+
+type auditRepo struct {
+	db dbtx
+}
+
+func NewAuditRepo(db dbtx) *auditRepo {
+	return &auditRepo{
+		db: db,
+	}
+}
+
+func (r *auditRepo) GetAllAudit(ctx context.Context, req *models.GetAllAuditRequest) (*models.GetAllAuditResponse, error) {
+	page, err := newListPage(req.ListRequest, auditSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllAuditResponse{}
+
+	resp.Entries = make([]models.AuditEntry, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + auditColumns + `
+			FROM ` + auditSource + `
+		`)
+	if req.EntityType != "" {
+		q.Where(`"entity_type" = ?`, req.EntityType)
+	}
+	if req.EntityID != "" {
+		q.Where(`"entity_id" = ?`, req.EntityID)
+	}
+	if req.Actor_id != "" {
+		q.Where(`"actor_id" = ?`, req.Actor_id)
+	}
+	if req.Action != "" {
+		q.Where(`"action" = ?`, string(req.Action))
+	}
+	if req.From != "" {
+		q.Where(`"created_at" >= ?::timestamptz`, req.From)
+	}
+	if req.To != "" {
+		q.Where(`"created_at" < ?::timestamptz`, req.To)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "audit log")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		entry, err := scanAuditEntry(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, entry.ID) {
+			break
+		}
+		resp.Entries = append(resp.Entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "audit log")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// change is a change of one entity on its way into the audit log. Its
+// statements run in tx, which commits them together with the log entry.
+type change struct {
+	tx     pgx.Tx
+	entity string
+	action models.AuditAction
+	before []byte
+}
+
+// beginChange starts a transaction, a savepoint when db is one already, for
+// action on the entity id and notes the entity as it is. id is empty for an
+// entity yet to be created.
+func beginChange(ctx context.Context, db dbtx, entity string, action models.AuditAction, id string) (*change, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := &change{tx: tx, entity: entity, action: action}
+	if id != "" {
+		if ch.before, err = snapshot(ctx, tx, entity, id); err != nil {
+			tx.Rollback(ctx)
+			return nil, err
+		}
+	}
+
+	return ch, nil
+}
+
+// update turns a change begun for an entity yet to be created into an update
+// of the existing entity id, and notes the entity as it is. The caller locks
+// its row first, so that it cannot change before it is noted.
+func (ch *change) update(ctx context.Context, id string) (err error) {
+	ch.action = models.AuditUpdate
+	ch.before, err = snapshot(ctx, ch.tx, ch.entity, id)
+	return err
+}
+
+// end logs the change of the entity id by the actor of ctx and commits it
+// when err is nil, and rolls it back otherwise. It returns err, or the error
+// of logging or committing.
+func (ch *change) end(ctx context.Context, id string, err error) error {
+	if err == nil {
+		err = ch.log(ctx, id)
+	}
+	if err != nil {
+		ch.tx.Rollback(ctx)
+		return err
+	}
+
+	return ch.tx.Commit(ctx)
+}
+
+func (ch *change) log(ctx context.Context, id string) error {
+	var (
+		after []byte
+		err   error
+	)
+	if ch.action != models.AuditDelete {
+		if after, err = snapshot(ctx, ch.tx, ch.entity, id); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO "audit_log"(
+			"id",
+			"actor_id",
+			"entity_type",
+			"entity_id",
+			"action",
+			"before",
+			"after",
+			"created_at")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		uuid.NewString(),
+		helper.NewNullString(storage.Actor(ctx)),
+		ch.entity,
+		id,
+		string(ch.action),
+		ch.before,
+		after,
+	)
+
+	return wrapError(err, "audit log")
+}
+
+// snapshot returns the row of the entity id as JSON without password and API
+// key hashes, or nil when there is none.
+func snapshot(ctx context.Context, db dbtx, entity, id string) ([]byte, error) {
+	query := `SELECT to_jsonb(t) - 'password_hash' - 'key_hash' FROM "` + auditTables[entity] + `" t WHERE t."id" = $1`
+
+	var row []byte
+	err := db.QueryRow(ctx, query, id).Scan(&row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapError(err, entity)
+	}
+
+	return row, nil
+}
+
+// scanAuditEntry scans auditColumns, after the leading columns of a list
+// query when lead is given.
+func scanAuditEntry(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.AuditEntry, error) {
+	var (
+		entry     models.AuditEntry
+		actorID   sql.NullString
+		actor     sql.NullString
+		action    string
+		before    []byte
+		after     []byte
+		createdAt time.Time
+	)
+	err := row.Scan(append(lead,
+		&entry.ID,
+		&actorID,
+		&actor,
+		&entry.EntityType,
+		&entry.EntityID,
+		&action,
+		&before,
+		&after,
+		&createdAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Actor_id = actorID.String
+	entry.Actor = actor.String
+	entry.Action = models.AuditAction(action)
+	entry.Before = before
+	entry.After = after
+	entry.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &entry, nil
+}
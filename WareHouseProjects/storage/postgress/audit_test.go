@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestUpsertAudit checks that the upserts log the first call as a creation
+// and the second as an update of the row as it was.
+func TestUpsertAudit(t *testing.T) {
+	ctx, s := testStore(t)
+
+	branchID, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: "NRT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entity string
+		upsert func(ctx context.Context, price string) (string, error)
+	}{
+		{"product", func(ctx context.Context, price string) (string, error) {
+			id, _, err := s.Product().UpsertProduct(ctx, &models.CreateProduct{Name: "Milk", Price: dec(price), Barcode: "1001"})
+			return id, err
+		}},
+		{"remain", func(ctx context.Context, price string) (string, error) {
+			return s.Remaining().AddRemain(ctx, &models.CreateRemain{Branch_id: branchID, Name: "Milk", Barcode: "1001", Price: dec(price), Cost: dec("1"), Count: dec("1"), TotalPrice: dec("1")})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entity, func(t *testing.T) {
+			first, err := tt.upsert(ctx, "10")
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := tt.upsert(ctx, "12")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second != first {
+				t.Fatalf("second upsert went to %s, want %s", second, first)
+			}
+
+			resp, err := s.Audit().GetAllAudit(ctx, &models.GetAllAuditRequest{ListRequest: page(), EntityType: tt.entity, EntityID: first})
+			if err != nil {
+				t.Fatal(err)
+			}
+			actions := map[models.AuditAction]models.AuditEntry{}
+			for _, e := range resp.Entries {
+				actions[e.Action] = e
+			}
+			if len(resp.Entries) != 2 || len(actions) != 2 {
+				t.Fatalf("audit = %+v, want a create and an update", resp.Entries)
+			}
+			if created := actions[models.AuditCreate]; created.Before != nil {
+				t.Errorf("create has before %s, want none", created.Before)
+			}
+			updated := actions[models.AuditUpdate]
+			if updated.Before == nil || updated.After == nil {
+				t.Fatalf("update has before %s and after %s, want both", updated.Before, updated.After)
+			}
+			if before := string(updated.Before); !strings.Contains(before, `"price": 10`) {
+				t.Errorf("update before = %s, want the price of 10", before)
+			}
+		})
+	}
+}
@@ -2,27 +2,36 @@ package postgres
 
 import (
 	"WareHouseProjects/models"
-	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// branchSortColumns are the fields the list may be sorted by.
+var branchSortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
 type branchRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewBranchRepo(db *pgxpool.Pool) *branchRepo {
+func NewBranchRepo(db dbtx) *branchRepo {
 	return &branchRepo{
 		db: db,
 	}
 }
 
-func (b *branchRepo) CreateBranch(req *models.CreateBranch) (string, error) {
+func (b *branchRepo) CreateBranch(ctx context.Context, req *models.CreateBranch) (resp string, err error) {
+	ch, err := beginChange(ctx, b.db, "branch", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
 
 	var (
 		id    = uuid.NewString()
@@ -33,31 +42,34 @@ func (b *branchRepo) CreateBranch(req *models.CreateBranch) (string, error) {
 		INSERT INTO "branches"(
 			"id", 
 			"name",
+			"code",
 			"address",
 			"phone",
 			"created_at" )
-		VALUES ($1, $2, $3, $4, NOW())`
+		VALUES ($1, $2, $3, $4, $5, NOW())`
 
-	_, err := b.db.Exec(context.Background(), query,
+	_, err = ch.tx.Exec(ctx, query,
 		id,
 		req.Name,
+		req.Code,
 		req.Address,
 		req.Phone,
 	)
 
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "branch")
 	}
 
 	return id, nil
 }
 
-func (b *branchRepo) GetBranch(req *models.BranchIdRequest) (resp *models.Branch, err error) {
+func (b *branchRepo) GetBranch(ctx context.Context, req *models.BranchIdRequest) (resp *models.Branch, err error) {
 
 	query := `
 		SELECT
 			"id", 
 			"name",
+			"code",
 			"address",
 			"phone",
 			"created_at",
@@ -71,16 +83,17 @@ func (b *branchRepo) GetBranch(req *models.BranchIdRequest) (resp *models.Branch
 	)
 
 	branch := models.Branch{}
-	err = b.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err = b.db.QueryRow(ctx, query, req.Id).Scan(
 		&branch.ID,
 		&branch.Name,
+		&branch.Code,
 		&branch.Address,
 		&branch.Phone,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("branch not found")
+		return nil, wrapError(err, "branch")
 	}
 	branch.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
@@ -90,45 +103,46 @@ func (b *branchRepo) GetBranch(req *models.BranchIdRequest) (resp *models.Branch
 	return &branch, nil
 }
 
-func (b *branchRepo) GetAllBranch(req *models.GetAllBranchRequest) (*models.GetAllBranchResponse, error) {
-	params := make(map[string]interface{})
+func (b *branchRepo) GetAllBranch(ctx context.Context, req *models.GetAllBranchRequest) (*models.GetAllBranchResponse, error) {
+	page, err := newListPage(req.ListRequest, branchSortColumns)
+	if err != nil {
+		return nil, err
+	}
 	var resp = &models.GetAllBranchResponse{}
 
 	resp.Branches = make([]models.Branch, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 			SELECT
-				COUNT(*) OVER(),
+				` + page.columns() + `
 				"id", 
 				"name",
+				"code",
 				"address",
 				"phone",
 				"created_at",
 				"updated_at" 
 			FROM "branches"
-		`
+		`)
 	if req.Name != "" {
-		filter += ` AND "name" ILIKE '%' || :search || '%' `
-		params["search"] = req.Name
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
 	}
 
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
+	page.apply(q)
+	rquery, args := q.Build()
 
-	rows, err := b.db.Query(context.Background(), rquery, pArr...)
+	rows, err := b.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "branch")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
+			sortKey   string
 			id        sql.NullString
 			name      sql.NullString
+			code      sql.NullString
 			address   sql.NullString
 			phone     sql.NullString
 			createdAt sql.NullString
@@ -136,8 +150,10 @@ func (b *branchRepo) GetAllBranch(req *models.GetAllBranchRequest) (*models.GetA
 		)
 		err := rows.Scan(
 			&resp.Count,
+			&sortKey,
 			&id,
 			&name,
+			&code,
 			&address,
 			&phone,
 			&createdAt,
@@ -146,50 +162,68 @@ func (b *branchRepo) GetAllBranch(req *models.GetAllBranchRequest) (*models.GetA
 		if err != nil {
 			return nil, err
 		}
+		if !page.keep(sortKey, id.String) {
+			break
+		}
 		resp.Branches = append(resp.Branches, models.Branch{
 			ID:        id.String,
 			Name:      name.String,
+			Code:      code.String,
 			Address:   address.String,
 			Phone:     phone.String,
 			CreatedAt: createdAt.String,
 			UpdatedAt: updatedAt.String,
 		})
 	}
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
 
-func (b *branchRepo) UpdateBranch(req *models.UpdateBranch) (string, error) {
+func (b *branchRepo) UpdateBranch(ctx context.Context, req *models.UpdateBranch) (resp string, err error) {
+	ch, err := beginChange(ctx, b.db, "branch", models.AuditUpdate, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
 
 	query := `UPDATE branches 
 	            SET  name = $1, 
-				     address = $2, 
-					 phone = $3, 
+				     code = $2, 
+				     address = $3, 
+					 phone = $4, 
 					 updated_at = NOW() 
-					 WHERE id = $4 RETURNING id`
+					 WHERE id = $5 RETURNING id`
 
-	result, err := b.db.Exec(context.Background(), query, req.Name, req.Address, req.Phone, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Name, req.Code, req.Address, req.Phone, req.Id)
 	if err != nil {
-		return "Error Update Branch", err
+		return "", wrapError(err, "branch")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("branch not found")
+		return "", notFound("branch")
 	}
 
 	return req.Id, nil
 }
 
-func (b *branchRepo) DeleteBranch(req *models.BranchIdRequest) (resp string, err error) {
+func (b *branchRepo) DeleteBranch(ctx context.Context, req *models.BranchIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, b.db, "branch", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
 	query := `DELETE FROM branches 
 	            WHERE id = $1 RETURNING id`
 
-	result, err := b.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id)
 	if err != nil {
-		return "Error from Delete Branch", err
+		return "", wrapError(err, "branch")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("branch not found")
+		return "", notFound("branch")
 	}
 
 	return req.Id, nil
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// branchPriceSortColumns are the fields the list may be sorted by.
+var branchPriceSortColumns = map[string]sortColumn{
+	"price":      {Name: "price", Type: "numeric"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const branchPriceColumns = `
+	"id",
+	"branch_id",
+	"product_id",
+	(SELECT "name" FROM "product" WHERE "product"."id" = "branch_price"."product_id"),
+	"price",
+	(SELECT "price" FROM "product" WHERE "product"."id" = "branch_price"."product_id"),
+	"created_at",
+	"updated_at"`
+
+type branchPriceRepo struct {
+	db dbtx
+}
+
+func NewBranchPriceRepo(db dbtx) *branchPriceRepo {
+	return &branchPriceRepo{
+		db: db,
+	}
+}
+
+func (r *branchPriceRepo) CreateBranchPrice(ctx context.Context, req *models.CreateBranchPrice) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "branch_price", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "branch_price"(
+			"id",
+			"branch_id",
+			"product_id",
+			"price",
+			"created_at")
+		VALUES ($1, $2, $3, $4, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Branch_id,
+		req.Product_id,
+		req.Price,
+	)
+	if err != nil {
+		return "", wrapError(err, "branch price")
+	}
+
+	return id, nil
+}
+
+func (r *branchPriceRepo) GetBranchPrice(ctx context.Context, req *models.BranchPriceIdRequest) (*models.BranchPrice, error) {
+	query := `SELECT ` + branchPriceColumns + ` FROM "branch_price" WHERE "id" = $1`
+
+	price, err := scanBranchPrice(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "branch price")
+	}
+
+	return price, nil
+}
+
+func (r *branchPriceRepo) GetAllBranchPrice(ctx context.Context, req *models.GetAllBranchPriceRequest) (*models.GetAllBranchPriceResponse, error) {
+	page, err := newListPage(req.ListRequest, branchPriceSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllBranchPriceResponse{}
+
+	resp.BranchPrices = make([]models.BranchPrice, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + branchPriceColumns + `
+			FROM "branch_price"
+		`)
+	if req.Branch_id != "" {
+		q.Where(`"branch_id" = ?`, req.Branch_id)
+	}
+	if req.Product_id != "" {
+		q.Where(`"product_id" = ?`, req.Product_id)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "branch price")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		price, err := scanBranchPrice(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, price.ID) {
+			break
+		}
+		resp.BranchPrices = append(resp.BranchPrices, *price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "branch price")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+func (r *branchPriceRepo) UpdateBranchPrice(ctx context.Context, req *models.UpdateBranchPrice) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "branch_price", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	query := `UPDATE "branch_price"
+	            SET "price" = $1,
+				    "updated_at" = NOW()
+				WHERE "id" = $2`
+
+	result, err := ch.tx.Exec(ctx, query, req.Price, req.ID)
+	if err != nil {
+		return "", wrapError(err, "branch price")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("branch price")
+	}
+
+	return req.ID, nil
+}
+
+func (r *branchPriceRepo) DeleteBranchPrice(ctx context.Context, req *models.BranchPriceIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "branch_price", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "branch_price" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "branch price")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("branch price")
+	}
+
+	return req.Id, nil
+}
+
+// scanBranchPrice scans branchPriceColumns, after the leading columns of a
+// list query when lead is given.
+func scanBranchPrice(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.BranchPrice, error) {
+	var (
+		price        models.BranchPrice
+		productName  sql.NullString
+		productPrice decimal.NullDecimal
+		createdAt    time.Time
+		updatedAt    sql.NullTime
+	)
+	err := row.Scan(append(lead,
+		&price.ID,
+		&price.Branch_id,
+		&price.Product_id,
+		&productName,
+		&price.Price,
+		&productPrice,
+		&createdAt,
+		&updatedAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	price.ProductName = productName.String
+	price.ProductPrice = productPrice.Decimal
+	price.CreatedAt = createdAt.Format(time.RFC3339)
+	if updatedAt.Valid {
+		price.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
+	}
+
+	return &price, nil
+}
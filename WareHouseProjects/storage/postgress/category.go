@@ -3,99 +3,99 @@ package postgres
 import (
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4"
 )
 
+// categorySortColumns are the fields the list may be sorted by.
+var categorySortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
 type categoryRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewCategoryRepo(db *pgxpool.Pool) *categoryRepo {
+func NewCategoryRepo(db dbtx) *categoryRepo {
 	return &categoryRepo{
 		db: db,
 	}
 }
 
-func (r *categoryRepo) CreateCategory(req *models.CreateCategory) (string, error) {
+func (r *categoryRepo) CreateCategory(ctx context.Context, req *models.CreateCategory) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "category", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
 	var (
 		id = uuid.NewString()
 	)
 
 	query := `
-		  INSERT INTO "category"(
-			"id",
-			"name",
-			"created_at")
-		  VALUES ($1, $2, NOW())`
-
-	if req.Parent_id != "" {
-		query = `
 		  INSERT INTO "category"(
 			"id",
 			"name",
 			"parent_id",
+			"tax_rate_id",
 			"created_at")
-		  VALUES ($1, $2, $3, NOW())`
-	}
+		  VALUES ($1, $2, $3, $4, NOW())`
 
-	if req.Parent_id != "" {
-		_, err := r.db.Exec(context.Background(), query,
-			id,
-			req.Name,
-			req.Parent_id,
-		)
-
-		if err != nil {
-			return "", err
-		}
-	} else {
-		_, err := r.db.Exec(context.Background(), query,
-			id,
-			req.Name,
-		)
-
-		if err != nil {
-			return "", err
-		}
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Name,
+		helper.NewNullString(req.Parent_id),
+		helper.NewNullString(req.Tax_rate_id),
+	)
+	if err != nil {
+		return "", wrapError(err, "category")
 	}
 
 	return id, nil
 }
 
-func (c *categoryRepo) GetCategory(req *models.CategoryIdRequest) (resp *models.Category, err error) {
+func (c *categoryRepo) GetCategory(ctx context.Context, req *models.CategoryIdRequest) (resp *models.Category, err error) {
 
 	query := `
 		SELECT
 		   "id", 
 		    "name",
 		    "parent_id",
+		    "tax_rate_id",
 		    "created_at", 
 			"updated_at" 
 		FROM "category"
 		WHERE id = $1
 	`
 	var (
-		createdAt time.Time
-		updatedAt sql.NullTime
+		parent_id   sql.NullString
+		tax_rate_id sql.NullString
+		createdAt   time.Time
+		updatedAt   sql.NullTime
 	)
 
 	category := models.Category{}
-	err = c.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err = c.db.QueryRow(ctx, query, req.Id).Scan(
 		&category.ID,
 		&category.Name,
-		&category.Parent_id,
+		&parent_id,
+		&tax_rate_id,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("category not found")
+		return nil, wrapError(err, "category")
 	}
+	category.Parent_id = parent_id.String
+	category.Tax_rate_id = tax_rate_id.String
 	category.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
 		category.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -104,103 +104,156 @@ func (c *categoryRepo) GetCategory(req *models.CategoryIdRequest) (resp *models.
 	return &category, nil
 }
 
-func (c *categoryRepo) GetAllCategory(req *models.GetAllCategoryRequest) (*models.GetAllCategoryResponse, error) {
-	params := make(map[string]interface{})
+func (c *categoryRepo) GetAllCategory(ctx context.Context, req *models.GetAllCategoryRequest) (*models.GetAllCategoryResponse, error) {
+	page, err := newListPage(req.ListRequest, categorySortColumns)
+	if err != nil {
+		return nil, err
+	}
 	var resp = &models.GetAllCategoryResponse{}
 
 	resp.Categories = make([]models.Category, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 			SELECT
-				COUNT(*) OVER(),
+				` + page.columns() + `
 				"id", 
 				"name",
 				"parent_id",
+				"tax_rate_id",
 				"created_at",
 				"updated_at" 
 			FROM "category"
-		`
+		`)
 	if req.Name != "" {
-		filter += ` AND "name" ILIKE '%' || :search || '%' `
-		params["search"] = req.Name
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
+	}
+	if req.Parent_id != "" {
+		q.Where(`"parent_id" = ?`, req.Parent_id)
 	}
 
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
+	page.apply(q)
+	rquery, args := q.Build()
 
-	rows, err := c.db.Query(context.Background(), rquery, pArr...)
+	rows, err := c.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "category")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
-			id        sql.NullString
-			name      sql.NullString
-			parent_id sql.NullString
-			createdAt sql.NullString
-			updatedAt sql.NullString
+			sortKey     string
+			id          sql.NullString
+			name        sql.NullString
+			parent_id   sql.NullString
+			tax_rate_id sql.NullString
+			createdAt   sql.NullString
+			updatedAt   sql.NullString
 		)
 		err := rows.Scan(
 			&resp.Count,
+			&sortKey,
 			&id,
 			&name,
 			&parent_id,
+			&tax_rate_id,
 			&createdAt,
 			&updatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if !page.keep(sortKey, id.String) {
+			break
+		}
 		resp.Categories = append(resp.Categories, models.Category{
-			ID:        id.String,
-			Name:      name.String,
-			Parent_id: parent_id.String,
-			CreatedAt: createdAt.String,
-			UpdatedAt: updatedAt.String,
+			ID:          id.String,
+			Name:        name.String,
+			Parent_id:   parent_id.String,
+			Tax_rate_id: tax_rate_id.String,
+			CreatedAt:   createdAt.String,
+			UpdatedAt:   updatedAt.String,
 		})
 	}
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
 
-func (c *categoryRepo) UpdateCategory(req *models.UpdateCategory) (string, error) {
+func (c *categoryRepo) UpdateCategory(ctx context.Context, req *models.UpdateCategory) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "category", models.AuditUpdate, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
 
 	query := `UPDATE category 
 	            SET  name = $1, 
 				     parent_id = $2, 
+				     tax_rate_id = $3, 
 					 updated_at = NOW() 
-					 WHERE id = $3 RETURNING id`
+					 WHERE id = $4 RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Name, req.Parent_id, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Name, helper.NewNullString(req.Parent_id), helper.NewNullString(req.Tax_rate_id), req.Id)
 	if err != nil {
-		return "Error Update Category", err
+		return "", wrapError(err, "category")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("category not found")
+		return "", notFound("category")
 	}
 
 	return req.Id, nil
 }
 
-func (c *categoryRepo) DeleteCategory(req *models.CategoryIdRequest) (resp string, err error) {
+func (c *categoryRepo) DeleteCategory(ctx context.Context, req *models.CategoryIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "category", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
 	query := `DELETE FROM category 
 	            WHERE id = $1 RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id)
 	if err != nil {
-		return "Error from Delete Category", err
+		return "", wrapError(err, "category")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Category not found")
+		return "", notFound("category")
 	}
 
 	return req.Id, nil
 }
+
+// EnsureCategoryPath returns the id of the last category of path, each one a
+// child of the one before, creating the categories that do not exist yet.
+// Names are matched case-insensitively.
+func (c *categoryRepo) EnsureCategoryPath(ctx context.Context, path []string) (string, int, error) {
+	var (
+		parentID sql.NullString
+		created  int
+	)
+
+	for _, name := range path {
+		var id string
+		err := c.db.QueryRow(ctx, `
+			SELECT "id"
+			FROM "category"
+			WHERE lower("name") = lower($1) AND "parent_id" IS NOT DISTINCT FROM $2
+			LIMIT 1
+		`, name, parentID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			id, err = c.CreateCategory(ctx, &models.CreateCategory{Name: name, Parent_id: parentID.String})
+			created++
+		}
+		if err != nil {
+			return "", 0, wrapError(err, "category")
+		}
+		parentID = sql.NullString{String: id, Valid: true}
+	}
+
+	return parentID.String, created, nil
+}
@@ -3,51 +3,91 @@ package postgres
 import (
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
+// comingTableSortColumns are the fields the list may be sorted by.
+var comingTableSortColumns = map[string]sortColumn{
+	"coming_id":   {Name: "coming_id", Type: "text"},
+	"date_time":   {Name: "date_time", Type: "timestamp"},
+	"status":      {Name: "status", Type: "text"},
+	"line_count":  {Name: "line_count", Type: "integer"},
+	"total_price": {Name: "total_price", Type: "numeric"},
+	"created_at":  {Name: "created_at", Type: "timestamp"},
+}
+
+// comingTableTotals joins the sums of the lines of each coming table as
+// "totals", in one aggregate per coming table rather than a query per row.
+// The tax of each line is rounded as GetComingTableTaxes does. A coming table
+// without lines totals 0.
+const comingTableTotals = `
+	LEFT JOIN LATERAL (
+		SELECT
+			COUNT(ctp."id") AS "line_count",
+			COALESCE(SUM(ctp."count"), 0) AS "total_count",
+			COALESCE(SUM(ctp."total_price"), 0) AS "total_price",
+			COALESCE(SUM(ctp."doc_total_price"), 0) AS "doc_total_price",
+			COALESCE(SUM(ctp."total_price"), 0) AS "net_amount",
+			COALESCE(SUM(ROUND(ctp."total_price" * ctp."tax_rate" / 100, 2)), 0) AS "tax_amount"
+		FROM "coming_table_product" ctp
+		WHERE ctp."coming_table_id" = "coming_table"."id"
+	) "totals" ON TRUE`
+
 type coming_tableRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewComingTableRepo(db *pgxpool.Pool) *coming_tableRepo {
+func NewComingTableRepo(db dbtx) *coming_tableRepo {
 	return &coming_tableRepo{
 		db: db,
 	}
 }
 
-func (c *coming_tableRepo) CreateComingTable(req *models.CreateComingTable) (resp string, err error) {
+func (c *coming_tableRepo) CreateComingTable(ctx context.Context, req *models.CreateComingTable) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	if err := checkBranchScope(ctx, req.Branch_id, "coming table"); err != nil {
+		return "", err
+	}
 	id := uuid.NewString()
-	// comingId := helper.NewCustomIDGenerator().GenerateID()
 
 	query := `
 	INSERT INTO coming_table(
 	  id,
 	  coming_id,
 	  branch_id,
-	  date_time
-	) VALUES($1,$2,$3,$4)	`
+	  date_time,
+	  currency,
+	  exchange_rate
+	) VALUES($1,$2,$3,$4,$5,$6)	`
 
-	_, err = c.db.Exec(context.Background(), query,
+	_, err = ch.tx.Exec(ctx, query,
 		id,
 		req.Coming_id,
 		req.Branch_id,
 		req.DateTime,
+		helper.NewNullString(req.Currency),
+		req.ExchangeRate,
 	)
 
 	if err != nil {
-		return
+		return "", wrapError(err, "coming table")
 	}
 
 	return id, nil
 }
-func (c *coming_tableRepo) GetComingTable(req *models.ComingTableIdRequest) (resp *models.ComingTable, err error) {
+func (c *coming_tableRepo) GetComingTable(ctx context.Context, req *models.ComingTableIdRequest) (resp *models.ComingTable, err error) {
 
 	query := `
 		SELECT
@@ -56,29 +96,50 @@ func (c *coming_tableRepo) GetComingTable(req *models.ComingTableIdRequest) (res
 		    "branch_id",
 		    "date_time",
 		    "status",
+		    "currency",
+		    "exchange_rate",
+		    "totals"."line_count",
+		    "totals"."total_count",
+		    "totals"."total_price",
+		    "totals"."doc_total_price",
+		    "totals"."net_amount",
+		    "totals"."tax_amount",
 		    "created_at",
 			"updated_at" 
-		FROM "coming_table"
-		WHERE id = $1
+		FROM "coming_table"` + comingTableTotals + `
+		WHERE id = $1 AND ($2::uuid IS NULL OR "branch_id" = $2)
 	`
 	var (
+		dateTime  sql.NullTime
+		currency  sql.NullString
 		createdAt time.Time
 		updatedAt sql.NullTime
 	)
 
 	ComingTable := models.ComingTable{}
-	err = c.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err = c.db.QueryRow(ctx, query, req.Id, branchScope(ctx)).Scan(
 		&ComingTable.ID,
 		&ComingTable.ComingID,
 		&ComingTable.BranchID,
-		&ComingTable.DateTime,
+		&dateTime,
 		&ComingTable.Status,
+		&currency,
+		&ComingTable.ExchangeRate,
+		&ComingTable.LineCount,
+		&ComingTable.TotalCount,
+		&ComingTable.TotalPrice,
+		&ComingTable.DocTotalPrice,
+		&ComingTable.NetAmount,
+		&ComingTable.TaxAmount,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf(" ComingTable not found")
+		return nil, wrapError(err, "coming table")
 	}
+	ComingTable.DateTime = dateTime.Time.Format(time.DateTime)
+	ComingTable.Currency = currency.String
+	ComingTable.GrossAmount = ComingTable.NetAmount.Add(ComingTable.TaxAmount)
 	ComingTable.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
 		ComingTable.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -87,143 +148,261 @@ func (c *coming_tableRepo) GetComingTable(req *models.ComingTableIdRequest) (res
 	return &ComingTable, nil
 }
 
-func (c *coming_tableRepo) GetAllComingTable(req *models.GetAllComingTableRequest) (*models.GetAllComingTableResponse, error) {
-	params := make(map[string]interface{})
+func (c *coming_tableRepo) GetAllComingTable(ctx context.Context, req *models.GetAllComingTableRequest) (*models.GetAllComingTableResponse, error) {
+	page, err := newListPage(req.ListRequest, comingTableSortColumns)
+	if err != nil {
+		return nil, err
+	}
 	var resp = &models.GetAllComingTableResponse{}
 
 	resp.ComingTables = make([]models.ComingTable, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 			SELECT
-				COUNT(*) OVER(),
+				` + page.columns() + `
 				"id", 
 				"coming_id",
 				"branch_id",
 				"date_time",
 				"status",
+				"currency",
+				"exchange_rate",
+				"totals"."line_count",
+				"totals"."total_count",
+				"totals"."total_price",
+				"totals"."doc_total_price",
+				"totals"."net_amount",
+				"totals"."tax_amount",
 				"created_at",
 				"updated_at" 
-			FROM "coming_table"
-		`
+			FROM "coming_table"` + comingTableTotals + `
+		`)
 
+	if scope := storage.BranchScope(ctx); scope != "" {
+		q.Where(`"branch_id" = ?`, scope)
+	}
 	if req.ComingID != "" {
-		filter += ` AND ("coming_id" ILIKE '%' || :coming_id ) `
-		params["search"] = req.ComingID
+		q.Where(`"coming_id" ILIKE '%' || ? || '%'`, req.ComingID)
 	}
 	if req.BranchID != "" {
-		filter += ` AND ("branch_id" ILIKE '%' || :branch_id ) `
-		params["search"] = req.BranchID
+		q.Where(`"branch_id" = ?`, req.BranchID)
 	}
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
+	if req.Status != "" {
+		q.Where(`"status" = ?`, string(req.Status))
+	}
+	if req.InBaseCurrency {
+		q.Where(`"currency" IS NULL`)
+	} else if req.Currency != "" {
+		q.Where(`"currency" = ?`, req.Currency)
+	}
+	if req.DateFrom != "" {
+		q.Where(`"date_time" >= ?::date`, req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.Where(`"date_time" < ?::date + 1`, req.DateTo)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
 
-	rows, err := c.db.Query(context.Background(), rquery, pArr...)
+	rows, err := c.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "coming table")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
+			sortKey   string
 			id        sql.NullString
 			coming_id sql.NullString
 			branch_id sql.NullString
 			date_time sql.NullTime
 			status    sql.NullString
+			currency  sql.NullString
+			rate      decimal.Decimal
+			lineCount int
+			count     decimal.Decimal
+			total     decimal.Decimal
+			docTotal  decimal.Decimal
+			net       decimal.Decimal
+			tax       decimal.Decimal
 			createdAt sql.NullString
 			updatedAt sql.NullString
 		)
 		err := rows.Scan(
 			&resp.Count,
+			&sortKey,
 			&id,
 			&coming_id,
 			&branch_id,
 			&date_time,
 			&status,
+			&currency,
+			&rate,
+			&lineCount,
+			&count,
+			&total,
+			&docTotal,
+			&net,
+			&tax,
 			&createdAt,
 			&updatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if !page.keep(sortKey, id.String) {
+			break
+		}
 		resp.ComingTables = append(resp.ComingTables, models.ComingTable{
-			ID:        id.String,
-			ComingID:  coming_id.String,
-			BranchID:  branch_id.String,
-			DateTime:  date_time.Time.Format(time.DateTime),
-			Status:    models.TableType(status.String),
-			CreatedAt: createdAt.String,
-			UpdatedAt: updatedAt.String,
+			ID:            id.String,
+			ComingID:      coming_id.String,
+			BranchID:      branch_id.String,
+			DateTime:      date_time.Time.Format(time.DateTime),
+			Status:        models.TableType(status.String),
+			Currency:      currency.String,
+			ExchangeRate:  rate,
+			LineCount:     lineCount,
+			TotalCount:    count,
+			TotalPrice:    total,
+			DocTotalPrice: docTotal,
+			NetAmount:     net,
+			TaxAmount:     tax,
+			GrossAmount:   net.Add(tax),
+			CreatedAt:     createdAt.String,
+			UpdatedAt:     updatedAt.String,
 		})
 	}
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
 
-func (c *coming_tableRepo) UpdateComingTable(req *models.UpdateComingTable) (string, error) {
+// UpdateComingTable overwrites the coming table and converts the costs of its
+// lines at the new exchange rate. Only a coming table in process can change,
+// and once it has a number it stays in the branch and year it was numbered
+// in.
+func (c *coming_tableRepo) UpdateComingTable(ctx context.Context, req *models.UpdateComingTable) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
 
-	query := `UPDATE coming_table 
-	            SET  coming_id = $1, 
-				     branch_id = $2, 
-					 date_time=$3,
-					 updated_at = NOW() 
-					 WHERE id = $4 RETURNING id`
+	if err := checkBranchScope(ctx, req.BranchID, "coming table"); err != nil {
+		return "", err
+	}
+
+	var (
+		status     string
+		numbered   bool
+		sameBranch bool
+		sameYear   bool
+	)
+	query := `
+		SELECT
+			"status",
+			COALESCE("coming_id", '') <> '',
+			"branch_id" = $3::uuid,
+			EXTRACT(YEAR FROM "date_time") = EXTRACT(YEAR FROM $4::timestamp)
+		FROM "coming_table"
+		WHERE "id" = $1 AND ($2::uuid IS NULL OR "branch_id" = $2)
+		FOR UPDATE`
 
-	result, err := c.db.Exec(context.Background(), query, req.ComingID, req.BranchID, req.DateTime, req.ID)
+	err = ch.tx.QueryRow(ctx, query, req.ID, branchScope(ctx), req.BranchID, req.DateTime).Scan(&status, &numbered, &sameBranch, &sameYear)
 	if err != nil {
-		return "Error Update Coming_Table", err
+		return "", wrapError(err, "coming table")
+	}
+	switch {
+	case status != string(models.InProcess):
+		return "", storage.NewError(storage.ErrInvalidState, "coming table already finished", nil)
+	case numbered && !sameBranch:
+		return "", storage.NewError(storage.ErrInvalidState, "branch of a numbered coming table cannot change", nil)
+	case numbered && !sameYear:
+		return "", storage.NewError(storage.ErrInvalidState, "year of a numbered coming table cannot change", nil)
 	}
 
-	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Coming_Table not found")
+	query = `
+		WITH updated AS (
+			UPDATE "coming_table"
+			SET "branch_id" = $1,
+				"date_time" = $2,
+				"currency" = $4,
+				"exchange_rate" = $5,
+				"updated_at" = NOW()
+			WHERE "id" = $3
+			RETURNING "id"
+		)
+		UPDATE "coming_table_product"
+		SET "cost" = "doc_cost" * $5,
+			"total_price" = "doc_total_price" * $5
+		WHERE "coming_table_id" IN (SELECT "id" FROM updated)`
+
+	_, err = ch.tx.Exec(ctx, query,
+		req.BranchID,
+		req.DateTime,
+		req.ID,
+		helper.NewNullString(req.Currency),
+		req.ExchangeRate,
+	)
+	if err != nil {
+		return "", wrapError(err, "coming table")
 	}
 
 	return req.ID, nil
 }
 
-func (c *coming_tableRepo) DeleteComingTable(req *models.ComingTableIdRequest) (resp string, err error) {
+func (c *coming_tableRepo) DeleteComingTable(ctx context.Context, req *models.ComingTableIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
 	query := `DELETE FROM coming_table 
-	            WHERE id = $1 RETURNING id`
+	            WHERE id = $1 AND ($2::uuid IS NULL OR branch_id = $2) RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id, branchScope(ctx))
 	if err != nil {
-		return "Error from Delete Coming_Table", err
+		return "", wrapError(err, "coming table")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Coming_Table not found")
+		return "", notFound("coming table")
 	}
 
 	return req.Id, nil
 }
-func (c *coming_tableRepo) UpdateStatus(req *models.ComingTableIdRequest) (string, error) {
+func (c *coming_tableRepo) UpdateStatus(ctx context.Context, req *models.ComingTableIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table", models.AuditUpdate, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
 	query := `Update coming_table Set
 	            status=$1,
 				updated_at=now()
 				where id=$2`
-	resp, err := c.db.Exec(context.Background(), query, "finished", req.Id)
+	result, err := ch.tx.Exec(ctx, query, models.Finished, req.Id)
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "coming table")
 	}
 
-	if resp.RowsAffected() == 0 {
-		return "", fmt.Errorf("coming_table with ID %s not found", req.Id)
+	if result.RowsAffected() == 0 {
+		return "", notFound("coming table")
 	}
 
 	return req.Id, nil
 }
 
-func (c *coming_tableRepo) GetStatus(req *models.ComingTableIdRequest) (string, error) {
+func (c *coming_tableRepo) GetStatus(ctx context.Context, req *models.ComingTableIdRequest) (string, error) {
 	var status sql.NullString
 
 	var branch_id sql.NullString
 	parsedUUID, err := uuid.Parse(req.Id)
 	if err != nil {
-		return "", fmt.Errorf("invalid UUID format: %v", err)
+		return "", storage.NewError(storage.ErrValidation, "invalid coming table id", err)
 	}
 
 	query := `
@@ -231,16 +410,17 @@ func (c *coming_tableRepo) GetStatus(req *models.ComingTableIdRequest) (string,
 		   status,
 		   branch_id
 		FROM coming_table
-		WHERE id = $1::uuid
+		WHERE id = $1::uuid AND ($2::uuid IS NULL OR branch_id = $2)
+		FOR UPDATE
 	`
 
-	err = c.db.QueryRow(context.Background(), query, parsedUUID).Scan(&status, &branch_id)
+	err = c.db.QueryRow(ctx, query, parsedUUID, branchScope(ctx)).Scan(&status, &branch_id)
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "coming table")
 	}
 
-	if status.Valid && status.String == "finished" {
-		return "", fmt.Errorf("coming table already finished")
+	if status.Valid && models.TableType(status.String) == models.Finished {
+		return "", storage.NewError(storage.ErrInvalidState, "coming table already finished", nil)
 	}
 
 	return branch_id.String, nil
@@ -3,31 +3,57 @@ package postgres
 import (
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
 	"context"
 	"database/sql"
-	"errors"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
+// comingTableProductSortColumns are the fields the list may be sorted by.
+var comingTableProductSortColumns = map[string]sortColumn{
+	"name":        {Name: "name", Type: "text"},
+	"price":       {Name: "price", Type: "numeric"},
+	"cost":        {Name: "cost", Type: "numeric"},
+	"barcode":     {Name: "barcode", Type: "text"},
+	"count":       {Name: "count", Type: "numeric"},
+	"total_price": {Name: "total_price", Type: "numeric"},
+	"created_at":  {Name: "created_at", Type: "timestamp"},
+}
+
+// comingTableProductUnit selects the unit short name and precision of the
+// product of the line in the enclosing query.
+const comingTableProductUnit = `
+	(SELECT u."short_name" FROM "product" p JOIN "unit" u ON u."id" = p."unit_id" WHERE p."barcode" = "coming_table_product"."barcode"),
+	(SELECT u."precision" FROM "product" p JOIN "unit" u ON u."id" = p."unit_id" WHERE p."barcode" = "coming_table_product"."barcode")`
+
 type coming_TableProductRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewComingTableProductRepo(db *pgxpool.Pool) *coming_TableProductRepo {
+func NewComingTableProductRepo(db dbtx) *coming_TableProductRepo {
 	return &coming_TableProductRepo{
 		db: db,
 	}
 }
 
-func (r *coming_TableProductRepo) CreateComingTableProduct(req *models.CreateComingTableProduct) (string, error) {
+func (r *coming_TableProductRepo) CreateComingTableProduct(ctx context.Context, req *models.CreateComingTableProduct) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "coming_table_product", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
 	var (
 		id    = uuid.NewString()
 		query string
 	)
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
 
 	query = `
 		INSERT INTO "coming_table_product"(
@@ -35,32 +61,40 @@ func (r *coming_TableProductRepo) CreateComingTableProduct(req *models.CreateCom
 			"category_id",
 			"name",
 			"price",
+			"cost",
+			"doc_cost",
 			"barcode",
 			"count",
 			"total_price",
+			"doc_total_price",
+			"tax_rate",
 			"coming_table_id",
 			"created_at" )
-		VALUES ($1, $2, $3, $4, $5,$6,$7,$8 NOW())`
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())`
 
-	_, err := r.db.Exec(context.Background(), query,
+	_, err = ch.tx.Exec(ctx, query,
 		id,
-		req.Category_id,
+		helper.NewNullString(req.Category_id),
 		req.Name,
 		req.Price,
+		req.Cost,
+		req.DocCost,
 		req.Barcode,
 		req.Count,
 		req.TotalPrice,
+		req.DocTotalPrice,
+		req.TaxRate,
 		req.Coming_Table_id,
 	)
 
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "coming table product")
 	}
 
 	return id, nil
 }
 
-func (c *coming_TableProductRepo) GetComingTableProduct(req *models.ComingTableProductIdRequest) (resp *models.ComingTableProduct, err error) {
+func (c *coming_TableProductRepo) GetComingTableProduct(ctx context.Context, req *models.ComingTableProductIdRequest) (resp *models.ComingTableProduct, err error) {
 
 	query := `
 		SELECT
@@ -68,36 +102,53 @@ func (c *coming_TableProductRepo) GetComingTableProduct(req *models.ComingTableP
 			"category_id",
 			"name",
 			"price",
+			"cost",
+			"doc_cost",
 			"barcode",
 			"count",
+			` + comingTableProductUnit + `,
 			"total_price",
+			"doc_total_price",
+			"tax_rate",
 			"coming_table_id",
 		    "created_at",
 			"updated_at" 
 		FROM "coming_table_product"
-		WHERE id = $1
+		WHERE id = $1 AND ($2::uuid IS NULL OR "coming_table_id" IN (SELECT "id" FROM "coming_table" WHERE "branch_id" = $2))
 	`
 	var (
-		createdAt time.Time
-		updatedAt sql.NullTime
+		category_id sql.NullString
+		unit        sql.NullString
+		precision   sql.NullInt32
+		createdAt   time.Time
+		updatedAt   sql.NullTime
 	)
 
 	ComingTableProduct := models.ComingTableProduct{}
-	err = c.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err = c.db.QueryRow(ctx, query, req.Id, branchScope(ctx)).Scan(
 		&ComingTableProduct.ID,
-		&ComingTableProduct.Category_id,
+		&category_id,
 		&ComingTableProduct.Name,
 		&ComingTableProduct.Price,
+		&ComingTableProduct.Cost,
+		&ComingTableProduct.DocCost,
 		&ComingTableProduct.Barcode,
 		&ComingTableProduct.Count,
+		&unit,
+		&precision,
 		&ComingTableProduct.TotalPrice,
+		&ComingTableProduct.DocTotalPrice,
+		&ComingTableProduct.TaxRate,
 		&ComingTableProduct.Coming_Table_id,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf(" ComingTableProduct not found")
+		return nil, wrapError(err, "coming table product")
 	}
+	ComingTableProduct.Category_id = category_id.String
+	ComingTableProduct.Unit, ComingTableProduct.Precision = unitOf(unit, precision)
+	lineAmounts(&ComingTableProduct)
 	ComingTableProduct.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
 		ComingTableProduct.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -106,72 +157,102 @@ func (c *coming_TableProductRepo) GetComingTableProduct(req *models.ComingTableP
 	return &ComingTableProduct, nil
 }
 
-func (c *coming_TableProductRepo) GetAllComingTableProduct(req *models.GetAllComingTableProductRequest) (*models.GetAllComingTableProductResponse, error) {
-	params := make(map[string]interface{})
+func (c *coming_TableProductRepo) GetAllComingTableProduct(ctx context.Context, req *models.GetAllComingTableProductRequest) (*models.GetAllComingTableProductResponse, error) {
+	page, err := newListPage(req.ListRequest, comingTableProductSortColumns)
+	if err != nil {
+		return nil, err
+	}
 	var resp = &models.GetAllComingTableProductResponse{}
 
 	resp.ComingTableProducts = make([]models.ComingTableProduct, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 			SELECT
-				COUNT(*) OVER(),
+				` + page.columns() + `
 				"id", 
 				"category_id",
 				"name",
 				"price",
+				"cost",
+				"doc_cost",
 				"barcode",
 				"count",
+				` + comingTableProductUnit + `,
 				"total_price",
+				"doc_total_price",
+				"tax_rate",
 				"coming_table_id",
 				"created_at",
 				"updated_at" 
 			FROM "coming_table_product"
-		`
+		`)
+	if scope := storage.BranchScope(ctx); scope != "" {
+		q.Where(`"coming_table_id" IN (SELECT "id" FROM "coming_table" WHERE "branch_id" = ?)`, scope)
+	}
+	if req.Coming_Table_id != "" {
+		q.Where(`"coming_table_id" = ?`, req.Coming_Table_id)
+	}
 	if req.Category_id != "" {
-		filter += ` AND ("category_id" ILIKE '%' || :category_id) `
-		params["search"] = req.Category_id
+		q.Where(`"category_id" = ?`, req.Category_id)
 	}
 	if req.Barcode != "" {
-		filter += ` AND ("barcode" ILIKE '%' || :barcode) `
-		params["search"] = req.Barcode
+		q.Where(`"barcode" = ?`, req.Barcode)
+	}
+	if req.Name != "" {
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
+	}
+	if req.PriceFrom != nil {
+		q.Where(`"price" >= ?`, *req.PriceFrom)
+	}
+	if req.PriceTo != nil {
+		q.Where(`"price" <= ?`, *req.PriceTo)
 	}
 
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
+	page.apply(q)
+	rquery, args := q.Build()
 
-	rows, err := c.db.Query(context.Background(), rquery, pArr...)
+	rows, err := c.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "coming table product")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
+			sortKey         string
 			id              sql.NullString
 			category_id     sql.NullString
 			name            sql.NullString
-			price           sql.NullFloat64
+			price           decimal.NullDecimal
+			cost            decimal.NullDecimal
+			doc_cost        decimal.NullDecimal
 			barcode         sql.NullString
-			count           sql.NullFloat64
-			total_price     sql.NullFloat64
+			count           decimal.NullDecimal
+			unit            sql.NullString
+			precision       sql.NullInt32
+			total_price     decimal.NullDecimal
+			doc_total_price decimal.NullDecimal
+			tax_rate        decimal.NullDecimal
 			coming_table_id sql.NullString
 			createdAt       sql.NullString
 			updatedAt       sql.NullString
 		)
 		err := rows.Scan(
 			&resp.Count,
+			&sortKey,
 			&id,
 			&category_id,
 			&name,
 			&price,
+			&cost,
+			&doc_cost,
 			&barcode,
 			&count,
+			&unit,
+			&precision,
 			&total_price,
+			&doc_total_price,
+			&tax_rate,
 			&coming_table_id,
 			&createdAt,
 			&updatedAt,
@@ -179,65 +260,103 @@ func (c *coming_TableProductRepo) GetAllComingTableProduct(req *models.GetAllCom
 		if err != nil {
 			return nil, err
 		}
-		resp.ComingTableProducts = append(resp.ComingTableProducts, models.ComingTableProduct{
+		if !page.keep(sortKey, id.String) {
+			break
+		}
+		line := models.ComingTableProduct{
 			ID:              id.String,
 			Category_id:     category_id.String,
 			Name:            name.String,
-			Price:           price.Float64,
+			Price:           price.Decimal,
+			Cost:            cost.Decimal,
+			DocCost:         doc_cost.Decimal,
 			Barcode:         barcode.String,
-			Count:           count.Float64,
-			TotalPrice:      total_price.Float64,
+			Count:           count.Decimal,
+			TotalPrice:      total_price.Decimal,
+			DocTotalPrice:   doc_total_price.Decimal,
+			TaxRate:         tax_rate.Decimal,
 			Coming_Table_id: coming_table_id.String,
 			CreatedAt:       createdAt.String,
 			UpdatedAt:       updatedAt.String,
-		})
+		}
+		line.Unit, line.Precision = unitOf(unit, precision)
+		lineAmounts(&line)
+		resp.ComingTableProducts = append(resp.ComingTableProducts, line)
 	}
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
 
-func (c *coming_TableProductRepo) UpdateComingTableProduct(req *models.UpdateComingTableProduct) (string, error) {
-	total_price := req.Count * req.Price
+// UpdateComingTableProduct overwrites the line. req.Cost is in the currency
+// of the coming table; without one the line keeps its current one. The cost
+// is converted at the exchange rate of the coming table and the totals are
+// recomputed at cost. A line limited to a branch cannot move to a coming table
+// of another one.
+func (c *coming_TableProductRepo) UpdateComingTableProduct(ctx context.Context, req *models.UpdateComingTableProduct) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table_product", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
 
 	query := `UPDATE coming_table_product 
 	            SET  category_id = $1, 
 				     name = $2, 
 					 price=$3,
-					 barcode=$4,
-					 count=$5,
-					 total_price=$6,
-					 comint_table_product=$7,
+					 cost=COALESCE(COALESCE(NULLIF($4::numeric, 0), doc_cost) * (SELECT exchange_rate FROM coming_table WHERE id = $7), cost),
+					 doc_cost=COALESCE(NULLIF($4::numeric, 0), doc_cost),
+					 barcode=$5,
+					 count=$6,
+					 total_price=$6 * COALESCE(COALESCE(NULLIF($4::numeric, 0), doc_cost) * (SELECT exchange_rate FROM coming_table WHERE id = $7), cost),
+					 doc_total_price=$6 * COALESCE(NULLIF($4::numeric, 0), doc_cost),
+					 coming_table_id=$7,
 					 updated_at = NOW() 
-					 WHERE id = $7 RETURNING id`
+					 WHERE id = $8 AND ($9::uuid IS NULL OR (
+					     coming_table_id IN (SELECT id FROM coming_table WHERE branch_id = $9) AND
+					     $7 IN (SELECT id FROM coming_table WHERE branch_id = $9)))
+					 RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Category_id, req.Name, req.Price, req.Barcode, req.Count, total_price, req.Coming_Table_id, req.ID)
+	result, err := ch.tx.Exec(ctx, query, helper.NewNullString(req.Category_id), req.Name, req.Price, req.Cost, req.Barcode, req.Count, req.Coming_Table_id, req.ID, branchScope(ctx))
 	if err != nil {
-		return "Error Update Coming_TableProduct", err
+		return "", wrapError(err, "coming table product")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Coming_TableProduct not found")
+		return "", notFound("coming table product")
 	}
 
 	return req.ID, nil
 }
 
-func (c *coming_TableProductRepo) DeleteComingTableProduct(req *models.ComingTableProductIdRequest) (resp string, err error) {
-	query := `DELETE FROM coming_TableProduct 
-	            WHERE id = $1 RETURNING id`
+func (c *coming_TableProductRepo) DeleteComingTableProduct(ctx context.Context, req *models.ComingTableProductIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table_product", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM coming_table_product 
+	            WHERE id = $1 AND ($2::uuid IS NULL OR coming_table_id IN (SELECT id FROM coming_table WHERE branch_id = $2))
+	            RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id, branchScope(ctx))
 	if err != nil {
-		return "Error from Delete coming_table_product", err
+		return "", wrapError(err, "coming table product")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Coming_TableProduct not found")
+		return "", notFound("coming table product")
 	}
 
 	return req.Id, nil
 }
 
-func (c *coming_TableProductRepo) CheckAviableProduct(req *models.CheckBarcodeComingTable) (string, error) {
+func (c *coming_TableProductRepo) CheckAviableProduct(ctx context.Context, req *models.CheckBarcodeComingTable) (string, error) {
 	var id sql.NullString
 
 	query := `Select
@@ -245,44 +364,172 @@ func (c *coming_TableProductRepo) CheckAviableProduct(req *models.CheckBarcodeCo
 			from coming_table_product
 			where barcode=$1 and coming_table_id=$2 `
 
-	err := c.db.QueryRow(context.Background(), query, req.Barcode, req.Coming_Table_id).Scan(&id)
+	err := c.db.QueryRow(ctx, query, req.Barcode, req.Coming_Table_id).Scan(&id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", errors.New("not found")
-		}
-		return "", err
+		return "", wrapError(err, "coming table product")
 	}
 
 	return id.String, nil
 }
 
-func (c *coming_TableProductRepo) UpdateIdAviable(req *models.UpdateComingTableProduct) (string, error) {
+// UpdateIdAviable adds req.Count units at req.TotalPrice, req.DocTotalPrice
+// in the currency of the coming table, to the line. The line costs become
+// the average costs of all its units. The line keeps the tax rate it was
+// first scanned at.
+func (c *coming_TableProductRepo) UpdateIdAviable(ctx context.Context, req *models.UpdateComingTableProduct) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "coming_table_product", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
+
 	query := `Update coming_table_product Set
 	           category_id=$1,
 			   barcode=$2,
 			   name=$3,
 			   price=$4,
+			   cost=(total_price+$6)/(count+$5),
+			   doc_cost=(doc_total_price+$9)/(count+$5),
 			   count=count+$5,
 			   total_price=total_price+$6,
+			   doc_total_price=doc_total_price+$9,
 			   coming_table_id=$7,
 			   updated_at=now()
 			   where id = $8  `
 
-	result, err := c.db.Exec(context.Background(), query,
-		req.Category_id,
+	result, err := ch.tx.Exec(ctx, query,
+		helper.NewNullString(req.Category_id),
 		req.Barcode,
 		req.Name,
 		req.Price,
 		req.Count,
 		req.TotalPrice,
+		req.Coming_Table_id,
 		req.ID,
+		req.DocTotalPrice,
 	)
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "coming table product")
 	}
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Not found this id in coming table product:  %s", req.Coming_Table_id)
+		return "", notFound("coming table product")
 	}
 	return req.Coming_Table_id, nil
 
 }
+
+func (c *coming_TableProductRepo) GetComingTableById(ctx context.Context, req *models.ComingTableProductIdRequest) ([]models.ComingTableProduct, error) {
+	query := `
+	SELECT
+		"id",
+		"category_id",
+		"name",
+		"price",
+		"cost",
+		"doc_cost",
+		"barcode",
+		"count",
+		` + comingTableProductUnit + `,
+		"total_price",
+		"doc_total_price",
+		"tax_rate",
+		"coming_table_id"
+	FROM "coming_table_product"
+	WHERE coming_table_id = $1
+	ORDER BY created_at
+`
+
+	rows, err := c.db.Query(ctx, query, req.Id)
+	if err != nil {
+		return nil, wrapError(err, "coming table product")
+	}
+	defer rows.Close()
+
+	resp := make([]models.ComingTableProduct, 0)
+	for rows.Next() {
+		var (
+			line        models.ComingTableProduct
+			category_id sql.NullString
+			unit        sql.NullString
+			precision   sql.NullInt32
+		)
+		err := rows.Scan(
+			&line.ID,
+			&category_id,
+			&line.Name,
+			&line.Price,
+			&line.Cost,
+			&line.DocCost,
+			&line.Barcode,
+			&line.Count,
+			&unit,
+			&precision,
+			&line.TotalPrice,
+			&line.DocTotalPrice,
+			&line.TaxRate,
+			&line.Coming_Table_id,
+		)
+		if err != nil {
+			return nil, err
+		}
+		line.Category_id = category_id.String
+		line.Unit, line.Precision = unitOf(unit, precision)
+		lineAmounts(&line)
+		resp = append(resp, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "coming table product")
+	}
+
+	return resp, nil
+}
+
+// GetComingTableTaxes totals the lines of the coming table by the rate they
+// are taxed at, lowest rate first. The tax of each rate is the sum of the
+// rounded taxes of its lines, so it matches the lines to the cent.
+func (c *coming_TableProductRepo) GetComingTableTaxes(ctx context.Context, req *models.ComingTableIdRequest) ([]models.ComingTableTax, error) {
+	query := `
+		SELECT
+			"tax_rate",
+			SUM("total_price"),
+			SUM(ROUND("total_price" * "tax_rate" / 100, 2))
+		FROM "coming_table_product"
+		WHERE "coming_table_id" = $1
+		GROUP BY "tax_rate"
+		ORDER BY "tax_rate"
+	`
+
+	rows, err := c.db.Query(ctx, query, req.Id)
+	if err != nil {
+		return nil, wrapError(err, "coming table product")
+	}
+	defer rows.Close()
+
+	resp := make([]models.ComingTableTax, 0)
+	for rows.Next() {
+		var tax models.ComingTableTax
+		if err := rows.Scan(&tax.TaxRate, &tax.NetAmount, &tax.TaxAmount); err != nil {
+			return nil, err
+		}
+		tax.GrossAmount = tax.NetAmount.Add(tax.TaxAmount)
+		resp = append(resp, tax)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "coming table product")
+	}
+
+	return resp, nil
+}
+
+// lineAmounts fills the net, tax and gross amounts of line from its total
+// and tax rate, rounding the tax to hundredths the way GetComingTableTaxes
+// does.
+func lineAmounts(line *models.ComingTableProduct) {
+	line.NetAmount = line.TotalPrice
+	line.TaxAmount = line.TotalPrice.Mul(line.TaxRate).Div(decimal.NewFromInt(100)).Round(2)
+	line.GrossAmount = line.NetAmount.Add(line.TaxAmount)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"testing"
+)
+
+func TestLineAmounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     string
+		taxRate   string
+		wantTax   string
+		wantGross string
+	}{
+		{"tenths", "0.3", "10", "0.03", "0.33"},
+		{"tax rounded up", "0.9", "12", "0.11", "1.01"},
+		{"tax rounded down", "10.02", "12", "1.2", "11.22"},
+		{"untaxed", "7.5", "0", "0", "7.5"},
+		{"large total", "19999980000.01001", "12", "2399997600", "22399977600.01001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := models.ComingTableProduct{TotalPrice: dec(tt.total), TaxRate: dec(tt.taxRate)}
+
+			lineAmounts(&line)
+
+			if line.NetAmount.String() != tt.total {
+				t.Errorf("net = %s, want %s", line.NetAmount, tt.total)
+			}
+			if line.TaxAmount.String() != tt.wantTax {
+				t.Errorf("tax = %s, want %s", line.TaxAmount, tt.wantTax)
+			}
+			if line.GrossAmount.String() != tt.wantGross {
+				t.Errorf("gross = %s, want %s", line.GrossAmount, tt.wantGross)
+			}
+		})
+	}
+}
+
+func TestComingTableProductAverageCost(t *testing.T) {
+	ctx, s := testStore(t)
+
+	branchID, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: "NRT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docID, err := s.Coming_Table().CreateComingTable(ctx, &models.CreateComingTable{Coming_id: "NRT-IN-2026-000001", Branch_id: branchID, DateTime: "2026-01-15 10:00:00", ExchangeRate: dec("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := s.Coming_TableProduct().CreateComingTableProduct(ctx, &models.CreateComingTableProduct{
+		Coming_Table_id: docID,
+		Name:            "Milk",
+		Barcode:         "1001",
+		Price:           dec("1"),
+		Count:           dec("3"),
+		Cost:            dec("0.1"),
+		DocCost:         dec("0.1"),
+		TotalPrice:      dec("0.3"),
+		DocTotalPrice:   dec("0.3"),
+		TaxRate:         dec("12"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Coming_TableProduct().UpdateIdAviable(ctx, &models.UpdateComingTableProduct{
+		ID:              id,
+		Coming_Table_id: docID,
+		Name:            "Milk",
+		Barcode:         "1001",
+		Price:           dec("1"),
+		Count:           dec("3"),
+		TotalPrice:      dec("0.6"),
+		DocTotalPrice:   dec("0.6"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := s.Coming_TableProduct().GetComingTableProduct(ctx, &models.ComingTableProductIdRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"count", line.Count.String(), "6"},
+		{"cost", line.Cost.String(), "0.15"},
+		{"doc cost", line.DocCost.String(), "0.15"},
+		{"total", line.TotalPrice.String(), "0.9"},
+		{"tax", line.TaxAmount.String(), "0.11"},
+		{"gross", line.GrossAmount.String(), "1.01"},
+	} {
+		if !dec(c.got).Equal(dec(c.want)) {
+			t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+		}
+	}
+
+	doc, err := s.Coming_Table().GetComingTable(ctx, &models.ComingTableIdRequest{Id: docID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !doc.TotalCount.Equal(dec("6")) || !doc.TotalPrice.Equal(dec("0.9")) || !doc.TaxAmount.Equal(dec("0.11")) {
+		t.Errorf("coming table totals = %s units, %s, tax %s; want 6 units, 0.9, tax 0.11", doc.TotalCount, doc.TotalPrice, doc.TaxAmount)
+	}
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/storage"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestUpdateComingTableKeepsNumber(t *testing.T) {
+	ctx, s := testStore(t)
+
+	north, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: "NRT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	south, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "South", Code: "STH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := s.Coming_Table().CreateComingTable(ctx, &models.CreateComingTable{Coming_id: "NRT-IN-2026-000001", Branch_id: north, DateTime: "2026-01-15 10:00:00", ExchangeRate: dec("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished, err := s.Coming_Table().CreateComingTable(ctx, &models.CreateComingTable{Coming_id: "NRT-IN-2026-000002", Branch_id: north, DateTime: "2026-01-16 10:00:00", ExchangeRate: dec("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Coming_Table().UpdateStatus(ctx, &models.ComingTableIdRequest{Id: finished}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		req     models.UpdateComingTable
+		wantErr error
+	}{
+		{"date in the same year", models.UpdateComingTable{ID: open, BranchID: north, DateTime: "2026-12-31 23:59:59", ExchangeRate: dec("1")}, nil},
+		{"another currency", models.UpdateComingTable{ID: open, BranchID: north, DateTime: "2026-01-15 10:00:00", Currency: "USD", ExchangeRate: dec("12600")}, nil},
+		{"another branch", models.UpdateComingTable{ID: open, BranchID: south, DateTime: "2026-01-15 10:00:00", ExchangeRate: dec("1")}, storage.ErrInvalidState},
+		{"another year", models.UpdateComingTable{ID: open, BranchID: north, DateTime: "2027-01-01 00:00:00", ExchangeRate: dec("1")}, storage.ErrInvalidState},
+		{"finished", models.UpdateComingTable{ID: finished, BranchID: north, DateTime: "2026-01-16 10:00:00", ExchangeRate: dec("1")}, storage.ErrInvalidState},
+		{"missing", models.UpdateComingTable{ID: "00000000-0000-0000-0000-000000000001", BranchID: north, DateTime: "2026-01-16 10:00:00", ExchangeRate: dec("1")}, storage.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Coming_Table().UpdateComingTable(ctx, &tt.req)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("err = %v, want none", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComingTableTotals(t *testing.T) {
+	ctx, s := testStore(t)
+
+	branchID, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: "NRT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		lines     [][2]string // count, cost
+		wantCount string
+		wantTotal string
+		wantTax   string
+	}{
+		{
+			name:      "tenths",
+			lines:     [][2]string{{"1", "0.1"}, {"1", "0.2"}},
+			wantCount: "2",
+			wantTotal: "0.3",
+			wantTax:   "0.03",
+		},
+		{
+			name: "ten lines of a tenth",
+			lines: [][2]string{
+				{"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"},
+				{"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"}, {"1", "0.1"},
+			},
+			wantCount: "10",
+			wantTotal: "1",
+			wantTax:   "0.1",
+		},
+		{
+			name:      "fractional counts",
+			lines:     [][2]string{{"0.333", "3"}, {"2.5", "1.15"}, {"0.7", "0.07"}},
+			wantCount: "3.533",
+			wantTotal: "3.923",
+			wantTax:   "0.48",
+		},
+		{
+			name:      "large arrival",
+			lines:     [][2]string{{"1000000", "0.01"}, {"999999", "19999.99"}, {"0.001", "0.01"}},
+			wantCount: "1999999.001",
+			wantTotal: "19999980000.01001",
+			wantTax:   "2399997600",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comingID := fmt.Sprintf("NRT-IN-2026-%06d", i+1)
+			docID, err := s.Coming_Table().CreateComingTable(ctx, &models.CreateComingTable{Coming_id: comingID, Branch_id: branchID, DateTime: "2026-01-15 10:00:00", ExchangeRate: dec("1")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j, l := range tt.lines {
+				line := models.CreateComingTableProduct{
+					Coming_Table_id: docID,
+					Name:            "Milk",
+					Barcode:         fmt.Sprint(1000 + j),
+					Price:           dec("1"),
+					Count:           dec(l[0]),
+					Cost:            dec(l[1]),
+					DocCost:         dec(l[1]),
+					TaxRate:         dec("12"),
+				}
+				line.TotalPrice = line.Cost.Mul(line.Count)
+				line.DocTotalPrice = line.TotalPrice
+				if _, err := s.Coming_TableProduct().CreateComingTableProduct(ctx, &line); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			doc, err := s.Coming_Table().GetComingTable(ctx, &models.ComingTableIdRequest{Id: docID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			list, err := s.Coming_Table().GetAllComingTable(ctx, &models.GetAllComingTableRequest{ComingID: comingID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.ComingTables) != 1 {
+				t.Fatalf("listed %d coming tables, want %s", len(list.ComingTables), comingID)
+			}
+
+			for _, got := range []models.ComingTable{*doc, list.ComingTables[0]} {
+				for _, c := range []struct {
+					field string
+					got   decimal.Decimal
+					want  string
+				}{
+					{"count", got.TotalCount, tt.wantCount},
+					{"total", got.TotalPrice, tt.wantTotal},
+					{"doc total", got.DocTotalPrice, tt.wantTotal},
+					{"tax", got.TaxAmount, tt.wantTax},
+				} {
+					if !c.got.Equal(dec(c.want)) {
+						t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"context"
+)
+
+type documentNumberRepo struct {
+	db dbtx
+}
+
+func NewDocumentNumberRepo(db dbtx) *documentNumberRepo {
+	return &documentNumberRepo{
+		db: db,
+	}
+}
+
+// NextDocumentNumber takes the next number of the sequence of req, starting
+// at 1 every year. The sequence stays locked until the transaction ends, so
+// it must run in the transaction that stores the document: concurrent
+// documents then get consecutive numbers, and a document that is rolled back
+// gives its number back.
+func (r *documentNumberRepo) NextDocumentNumber(ctx context.Context, req *models.NextDocumentNumber) (*models.DocumentNumber, error) {
+	query := `
+		WITH branch AS (
+			SELECT "id", "code" FROM "branches" WHERE "id" = $1
+		), next AS (
+			INSERT INTO "document_sequence"("branch_id", "document_type", "year", "last_number")
+			SELECT "id", $2, $3, 1 FROM branch
+			ON CONFLICT ("branch_id", "document_type", "year") DO UPDATE
+				SET "last_number" = "document_sequence"."last_number" + 1
+			RETURNING "last_number"
+		)
+		SELECT branch."code", next."last_number" FROM branch, next`
+
+	number := models.DocumentNumber{Type: req.Type, Year: req.Year}
+	err := r.db.QueryRow(ctx, query, req.BranchID, string(req.Type), req.Year).Scan(&number.BranchCode, &number.Number)
+	if err != nil {
+		return nil, wrapError(err, "branch")
+	}
+
+	return &number, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"WareHouseProjects/storage"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Postgres error codes the repos translate into storage errors.
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+	codeNotNullViolation    = "23502"
+	codeCheckViolation      = "23514"
+	codeInvalidText         = "22P02"
+	codeInvalidDatetime     = "22007"
+	codeDatetimeOverflow    = "22008"
+	codeNumericOverflow     = "22003"
+)
+
+// wrapError translates pgx and Postgres failures on entity into storage
+// errors. Errors it does not recognise are returned unchanged.
+func wrapError(err error, entity string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound(entity)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case codeUniqueViolation:
+		return storage.NewError(storage.ErrConflict, fmt.Sprintf("%s already exists: %s", entity, detail(pgErr)), err)
+	case codeForeignKeyViolation:
+		if strings.Contains(pgErr.Detail, "is still referenced") {
+			return storage.NewError(storage.ErrConflict, fmt.Sprintf("%s is still referenced by other records", entity), err)
+		}
+		return storage.NewError(storage.ErrValidation, fmt.Sprintf("%s references a missing record: %s", entity, detail(pgErr)), err)
+	case codeNotNullViolation, codeCheckViolation, codeInvalidText, codeInvalidDatetime, codeDatetimeOverflow, codeNumericOverflow:
+		return storage.NewFieldError(storage.ErrValidation, pgErr.ColumnName, fmt.Sprintf("invalid %s: %s", entity, pgErr.Message), err)
+	}
+
+	return err
+}
+
+func notFound(entity string) error {
+	return storage.NewError(storage.ErrNotFound, entity+" not found", nil)
+}
+
+func detail(pgErr *pgconn.PgError) string {
+	if pgErr.Detail != "" {
+		return pgErr.Detail
+	}
+	return pgErr.Message
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// exchangeRateSortColumns are the fields the list may be sorted by.
+var exchangeRateSortColumns = map[string]sortColumn{
+	"currency":   {Name: "currency", Type: "text"},
+	"date":       {Name: "date", Type: "date"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const exchangeRateColumns = `
+	"id",
+	"currency",
+	"rate",
+	"date",
+	"created_at",
+	"updated_at"`
+
+type exchangeRateRepo struct {
+	db dbtx
+}
+
+func NewExchangeRateRepo(db dbtx) *exchangeRateRepo {
+	return &exchangeRateRepo{
+		db: db,
+	}
+}
+
+func (r *exchangeRateRepo) CreateExchangeRate(ctx context.Context, req *models.CreateExchangeRate) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "exchange_rate", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "exchange_rate"(
+			"id",
+			"currency",
+			"rate",
+			"date",
+			"created_at")
+		VALUES ($1, $2, $3, $4, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Currency,
+		req.Rate,
+		req.Date,
+	)
+	if err != nil {
+		return "", wrapError(err, "exchange rate")
+	}
+
+	return id, nil
+}
+
+func (r *exchangeRateRepo) GetExchangeRate(ctx context.Context, req *models.ExchangeRateIdRequest) (*models.ExchangeRate, error) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM "exchange_rate" WHERE "id" = $1`
+
+	rate, err := scanExchangeRate(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "exchange rate")
+	}
+
+	return rate, nil
+}
+
+// GetExchangeRateOn returns the rate of req.Currency in force on req.Date, a
+// validation error when none was set on or before it.
+func (r *exchangeRateRepo) GetExchangeRateOn(ctx context.Context, req *models.ExchangeRateOn) (*models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM "exchange_rate"
+		WHERE "currency" = $1 AND "date" <= CAST($2 AS date)
+		ORDER BY "date" DESC
+		LIMIT 1`
+
+	rate, err := scanExchangeRate(r.db.QueryRow(ctx, query, req.Currency, req.Date))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.NewError(storage.ErrValidation, fmt.Sprintf("no exchange rate of %s on %s", req.Currency, req.Date), nil)
+	}
+	if err != nil {
+		return nil, wrapError(err, "exchange rate")
+	}
+
+	return rate, nil
+}
+
+func (r *exchangeRateRepo) GetAllExchangeRate(ctx context.Context, req *models.GetAllExchangeRateRequest) (*models.GetAllExchangeRateResponse, error) {
+	page, err := newListPage(req.ListRequest, exchangeRateSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllExchangeRateResponse{}
+
+	resp.ExchangeRates = make([]models.ExchangeRate, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + exchangeRateColumns + `
+			FROM "exchange_rate"
+		`)
+	if req.Currency != "" {
+		q.Where(`"currency" = ?`, req.Currency)
+	}
+	if req.DateFrom != "" {
+		q.Where(`"date" >= ?::date`, req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.Where(`"date" <= ?::date`, req.DateTo)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "exchange rate")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		rate, err := scanExchangeRate(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, rate.ID) {
+			break
+		}
+		resp.ExchangeRates = append(resp.ExchangeRates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "exchange rate")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// UpdateExchangeRate overwrites the rate. Coming tables keep the rate they
+// were converted at.
+func (r *exchangeRateRepo) UpdateExchangeRate(ctx context.Context, req *models.UpdateExchangeRate) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "exchange_rate", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	query := `UPDATE "exchange_rate"
+	            SET "currency" = $1,
+				    "rate" = $2,
+				    "date" = $3,
+				    "updated_at" = NOW()
+				WHERE "id" = $4`
+
+	result, err := ch.tx.Exec(ctx, query, req.Currency, req.Rate, req.Date, req.ID)
+	if err != nil {
+		return "", wrapError(err, "exchange rate")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("exchange rate")
+	}
+
+	return req.ID, nil
+}
+
+func (r *exchangeRateRepo) DeleteExchangeRate(ctx context.Context, req *models.ExchangeRateIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "exchange_rate", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "exchange_rate" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "exchange rate")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("exchange rate")
+	}
+
+	return req.Id, nil
+}
+
+// scanExchangeRate scans exchangeRateColumns, after the leading columns of a
+// list query when lead is given.
+func scanExchangeRate(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.ExchangeRate, error) {
+	var (
+		rate      models.ExchangeRate
+		date      time.Time
+		createdAt time.Time
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(append(lead,
+		&rate.ID,
+		&rate.Currency,
+		&rate.Rate,
+		&date,
+		&createdAt,
+		&updatedAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	rate.Date = date.Format(time.DateOnly)
+	rate.CreatedAt = createdAt.Format(time.RFC3339)
+	if updatedAt.Valid {
+		rate.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	migrations "WareHouseProjects/Migrations"
+	"WareHouseProjects/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// migrationLockID keeps two binaries from migrating the same database at once.
+const migrationLockID = 7_316_002
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+func NewMigrator(ctx context.Context, cfg config.Config) (*Migrator, error) {
+	list, err := loadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: list,
+	}, nil
+}
+
+func (m *Migrator) Close() {
+	m.db.Close()
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, mig := range m.migrations {
+		ran := false
+		err := m.run(ctx, func(tx pgx.Tx, applied map[int64]time.Time) error {
+			if _, ok := applied[mig.Version]; ok {
+				return nil
+			}
+			if _, err := tx.Exec(ctx, mig.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO "schema_migrations"("version", "name") VALUES ($1, $2)`, mig.Version, mig.Name)
+			ran = err == nil
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %03d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		if ran {
+			done = append(done, mig)
+		}
+	}
+
+	return done, nil
+}
+
+// Down reverts the most recently applied migration. It returns nil when
+// nothing is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var reverted *Migration
+	err := m.run(ctx, func(tx pgx.Tx, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.down == "" {
+				return fmt.Errorf("migration %03d_%s has no down file", mig.Version, mig.Name)
+			}
+
+			if _, err := tx.Exec(ctx, mig.down); err != nil {
+				return fmt.Errorf("migration %03d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.Exec(ctx, `DELETE FROM "schema_migrations" WHERE "version" = $1`, mig.Version); err != nil {
+				return fmt.Errorf("migration %03d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted = &mig
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if appliedAt, ok := applied[mig.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = appliedAt.Format(time.RFC3339)
+		}
+		resp = append(resp, status)
+	}
+
+	return resp, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" bigint PRIMARY KEY,
+			"name" varchar NOT NULL,
+			"applied_at" timestamp NOT NULL DEFAULT current_timestamp
+		)`
+
+	_, err := m.db.Exec(ctx, query)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, db dbtx) (map[int64]time.Time, error) {
+	rows, err := db.Query(ctx, `SELECT "version", "applied_at" FROM "schema_migrations"`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt sql.NullTime
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		resp[version] = appliedAt.Time
+	}
+
+	return resp, rows.Err()
+}
+
+// run calls apply in a single transaction guarded by an advisory lock, with
+// the versions applied as read under that lock, so that two binaries starting
+// together do not apply the same migration twice.
+func (m *Migrator) run(ctx context.Context, apply func(tx pgx.Tx, applied map[int64]time.Time) error) error {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := apply(tx, applied); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		var direction string
+		base := file
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			direction, base = "up", strings.TrimSuffix(file, ".up.sql")
+		case strings.HasSuffix(file, ".down.sql"):
+			direction, base = "down", strings.TrimSuffix(file, ".down.sql")
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", file)
+		}
+
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", file)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		}
+		if mig.Name != name {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s", version, mig.Name, name)
+		}
+
+		if direction == "up" {
+			mig.up = string(body)
+		} else {
+			mig.down = string(body)
+		}
+	}
+
+	resp := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", mig.Version, mig.Name)
+		}
+		resp = append(resp, *mig)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Version < resp[j].Version })
+
+	return resp, nil
+}
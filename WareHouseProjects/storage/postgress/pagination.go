@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const defaultSort = "created_at:desc"
+
+// sortColumn is a column a list may be ordered by. Type is the Postgres type
+// a cursor key is cast back to when it is compared with the column.
+type sortColumn struct {
+	Name string
+	Type string
+}
+
+// cursor is the decoded form of next_cursor: the sort it was issued for and
+// the sort key and id of the last row of the previous page.
+type cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"i"`
+}
+
+// listPage orders and pages one list query. Lists are ordered by the sort
+// column and then by id, so that rows with equal keys keep a stable order and
+// a cursor can resume right after the last row it saw.
+type listPage struct {
+	sort   string
+	column sortColumn
+	desc   bool
+	limit  int
+	offset int
+	after  *cursor
+
+	rows    int
+	more    bool
+	lastKey string
+	lastID  string
+}
+
+// newListPage validates req.Sort against columns and decodes req.Cursor.
+func newListPage(req models.ListRequest, columns map[string]sortColumn) (*listPage, error) {
+	spec := strings.ToLower(strings.TrimSpace(req.Sort))
+	if spec == "" {
+		spec = defaultSort
+	}
+
+	field, dir, _ := strings.Cut(spec, ":")
+	if dir == "" {
+		dir = "asc"
+	}
+	column, ok := columns[field]
+	if !ok {
+		return nil, storage.NewError(storage.ErrValidation, fmt.Sprintf("cannot sort by %q, use one of: %s", field, sortFields(columns)), nil)
+	}
+	if dir != "asc" && dir != "desc" {
+		return nil, storage.NewError(storage.ErrValidation, fmt.Sprintf("invalid sort direction %q, use asc or desc", dir), nil)
+	}
+
+	p := &listPage{
+		sort:   field + ":" + dir,
+		column: column,
+		desc:   dir == "desc",
+		limit:  req.Limit,
+		offset: (req.Page - 1) * req.Limit,
+	}
+
+	if req.Cursor != "" {
+		after, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if after.Sort != p.sort {
+			return nil, storage.NewError(storage.ErrValidation, "cursor was issued for another sort", nil)
+		}
+		p.after = after
+	}
+
+	return p, nil
+}
+
+// columns returns the leading select columns every list query scans first:
+// the total number of matching rows and the sort key of the row. The total is
+// not counted when paging by cursor.
+func (p *listPage) columns() string {
+	total := "COUNT(*) OVER()"
+	if p.after != nil {
+		total = "0"
+	}
+	return fmt.Sprintf(`%s, "%s"::text,`, total, p.column.Name)
+}
+
+// apply adds the ordering and paging of the list to q. Rows up to the cursor
+// are skipped, and one row more than the limit is fetched to tell whether
+// another page follows.
+func (p *listPage) apply(q *query.Builder) {
+	dir := "ASC"
+	if p.desc {
+		dir = "DESC"
+	}
+
+	if p.after != nil {
+		q.Where(fmt.Sprintf(`("%s", "id") %s (CAST(CAST(? AS text) AS %s), CAST(? AS uuid))`, p.column.Name, p.compare(), p.column.Type), p.after.Key, p.after.ID)
+	}
+	q.OrderBy(fmt.Sprintf(`"%s" %s`, p.column.Name, dir), `"id" `+dir)
+	q.Limit(p.limit + 1)
+	if p.after == nil {
+		q.Offset(p.offset)
+	}
+}
+
+// keep records a scanned row and reports whether it belongs to the page.
+// It returns false for the extra row fetched by apply.
+func (p *listPage) keep(key, id string) bool {
+	p.rows++
+	if p.rows > p.limit {
+		p.more = true
+		return false
+	}
+	p.lastKey, p.lastID = key, id
+	return true
+}
+
+// nextCursor returns the cursor of the page after the kept rows, or "" when
+// this was the last page.
+func (p *listPage) nextCursor() string {
+	if !p.more {
+		return ""
+	}
+
+	data, _ := json.Marshal(cursor{Sort: p.sort, Key: p.lastKey, ID: p.lastID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (p *listPage) compare() string {
+	if p.desc {
+		return "<"
+	}
+	return ">"
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	invalid := storage.NewError(storage.ErrValidation, "invalid cursor", nil)
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort == "" || c.ID == "" {
+		return nil, invalid
+	}
+	return &c, nil
+}
+
+func sortFields(columns map[string]sortColumn) string {
+	fields := make([]string, 0, len(columns))
+	for field := range columns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return strings.Join(fields, ", ")
+}
@@ -6,20 +6,53 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// dbtx is satisfied by both *pgxpool.Pool and pgx.Tx, so the same repos run
+// inside and outside a transaction.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type store struct {
-	db                  *pgxpool.Pool
+	db                  dbtx
+	pool                *pgxpool.Pool
 	branches            *branchRepo
+	branchPrice         *branchPriceRepo
 	category            *categoryRepo
 	product             *productRepo
+	productPrice        *productPriceRepo
 	coming_table        *coming_tableRepo
 	coming_tableProduct *coming_TableProductRepo
 	remain              *remainRepo
+	unit                *unitRepo
+	exchangeRate        *exchangeRateRepo
+	taxRate             *taxRateRepo
+	documentNumber      *documentNumberRepo
+	user                *userRepo
+	apiKey              *apiKeyRepo
+	audit               *auditRepo
 }
 
 func NewStorage(ctx context.Context, cfg config.Config) (storage.StorageI, error) {
+	pgxpool, err := connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store{
+		db:   pgxpool,
+		pool: pgxpool,
+	}, nil
+}
+
+func connect(ctx context.Context, cfg config.Config) (*pgxpool.Pool, error) {
 	connect, err := pgxpool.ParseConfig(fmt.Sprintf(
 		"host=%s user=%s dbname=%s password=%s port=%d sslmode=disable",
 		cfg.PostgresHost,
@@ -34,14 +67,7 @@ func NewStorage(ctx context.Context, cfg config.Config) (storage.StorageI, error
 	}
 	connect.MaxConns = cfg.PostgresMaxConnections
 
-	pgxpool, err := pgxpool.ConnectConfig(context.Background(), connect)
-	if err != nil {
-		return nil, err
-	}
-
-	return &store{
-		db: pgxpool,
-	}, nil
+	return pgxpool.ConnectConfig(ctx, connect)
 }
 
 func (b *store) Branch() storage.BranchesI {
@@ -51,6 +77,13 @@ func (b *store) Branch() storage.BranchesI {
 	return b.branches
 }
 
+func (b *store) BranchPrice() storage.BranchPricesI {
+	if b.branchPrice == nil {
+		b.branchPrice = NewBranchPriceRepo(b.db)
+	}
+	return b.branchPrice
+}
+
 func (b *store) Category() storage.CategoriesI {
 	if b.category == nil {
 		b.category = NewCategoryRepo(b.db)
@@ -65,6 +98,13 @@ func (b *store) Product() storage.ProdouctsI {
 	return b.product
 }
 
+func (b *store) ProductPrice() storage.ProductPricesI {
+	if b.productPrice == nil {
+		b.productPrice = NewProductPriceRepo(b.db)
+	}
+	return b.productPrice
+}
+
 func (b *store) Coming_Table() storage.Coming_TableI {
 	if b.coming_table == nil {
 		b.coming_table = NewComingTableRepo(b.db)
@@ -86,6 +126,82 @@ func (b *store) Remaining() storage.RemainingI {
 	return b.remain
 }
 
+func (b *store) Unit() storage.UnitsI {
+	if b.unit == nil {
+		b.unit = NewUnitRepo(b.db)
+	}
+	return b.unit
+}
+
+func (b *store) ExchangeRate() storage.ExchangeRatesI {
+	if b.exchangeRate == nil {
+		b.exchangeRate = NewExchangeRateRepo(b.db)
+	}
+	return b.exchangeRate
+}
+
+func (b *store) TaxRate() storage.TaxRatesI {
+	if b.taxRate == nil {
+		b.taxRate = NewTaxRateRepo(b.db)
+	}
+	return b.taxRate
+}
+
+func (b *store) DocumentNumber() storage.DocumentNumbersI {
+	if b.documentNumber == nil {
+		b.documentNumber = NewDocumentNumberRepo(b.db)
+	}
+	return b.documentNumber
+}
+
+func (b *store) User() storage.UsersI {
+	if b.user == nil {
+		b.user = NewUserRepo(b.db)
+	}
+	return b.user
+}
+
+func (b *store) ApiKey() storage.ApiKeysI {
+	if b.apiKey == nil {
+		b.apiKey = NewApiKeyRepo(b.db)
+	}
+	return b.apiKey
+}
+
+func (b *store) Audit() storage.AuditI {
+	if b.audit == nil {
+		b.audit = NewAuditRepo(b.db)
+	}
+	return b.audit
+}
+
+// WithTx runs fn against a store whose repos all share one transaction. The
+// transaction is committed when fn returns nil and rolled back when it returns
+// an error or panics. Calling WithTx on a transactional store opens a savepoint.
+func (s *store) WithTx(ctx context.Context, fn func(storage.StorageI) error) (err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
+	return fn(&store{db: tx})
+}
+
+// Close releases the pool. It is a no-op on a transactional store.
 func (s *store) Close() {
-	s.db.Close()
+	if s.pool != nil {
+		s.pool.Close()
+	}
 }
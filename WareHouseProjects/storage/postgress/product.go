@@ -3,88 +3,181 @@ package postgres
 import (
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
 )
 
+// productSortColumns are the fields the list may be sorted by.
+var productSortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"price":      {Name: "price", Type: "numeric"},
+	"barcode":    {Name: "barcode", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+// productLastCost selects the purchase cost of the product of the enclosing
+// query in its last finished arrival, NULL if it never arrived.
+const productLastCost = `(
+	SELECT ctp."cost"
+	FROM "coming_table_product" ctp
+	JOIN "coming_table" ct ON ct."id" = ctp."coming_table_id"
+	WHERE ctp."barcode" = "product"."barcode" AND ct."status" = 'finished'
+	ORDER BY ct."date_time" DESC, ctp."created_at" DESC
+	LIMIT 1
+)`
+
+// productUnit selects the unit id, short name and precision of the product
+// of the enclosing query, all NULL for a product without a unit.
+const productUnit = `
+	"unit_id",
+	(SELECT u."short_name" FROM "unit" u WHERE u."id" = "product"."unit_id"),
+	(SELECT u."precision" FROM "unit" u WHERE u."id" = "product"."unit_id")`
+
+// productTax selects the tax rate id of the product of the enclosing query
+// and the percent it is taxed at: the rate of the product, else that of its
+// category or the nearest parent category with one, else 0. The walk up the
+// categories is bounded so that a cycle of parents cannot loop forever.
+const productTax = `
+	"tax_rate_id",
+	COALESCE(
+		(SELECT t."rate" FROM "tax_rate" t WHERE t."id" = "product"."tax_rate_id"),
+		(
+			WITH RECURSIVE chain AS (
+				SELECT g."parent_id", g."tax_rate_id", 0 AS "depth"
+				FROM "category" g
+				WHERE g."id" = "product"."category_id"
+				UNION ALL
+				SELECT g."parent_id", g."tax_rate_id", chain."depth" + 1
+				FROM chain
+				JOIN "category" g ON g."id" = chain."parent_id"
+				WHERE chain."tax_rate_id" IS NULL AND chain."depth" < 32
+			)
+			SELECT t."rate"
+			FROM chain
+			JOIN "tax_rate" t ON t."id" = chain."tax_rate_id"
+			ORDER BY chain."depth"
+			LIMIT 1
+		),
+		0
+	)`
+
 type productRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewProductRepo(db *pgxpool.Pool) *productRepo {
+func NewProductRepo(db dbtx) *productRepo {
 	return &productRepo{
 		db: db,
 	}
 }
 
-func (r *productRepo) CreateProduct(req *models.CreateProduct) (string, error) {
+func (r *productRepo) CreateProduct(ctx context.Context, req *models.CreateProduct) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "product", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
 	var (
 		id = uuid.NewString()
 	)
 
+	// The initial price opens the price history of the product.
 	query := `
-				INSERT INTO "product"(
-					"id",
-					"name",
-					"price",
-					"barcode",
-					"category_id",
-					"created_at")
-				VALUES ($1, $2, $3, $4, $5, NOW())`
-
-	_, err := r.db.Exec(context.Background(), query,
+		WITH created AS (
+			INSERT INTO "product"(
+				"id",
+				"name",
+				"price",
+				"barcode",
+				"category_id",
+				"unit_id",
+				"tax_rate_id",
+				"created_at")
+			VALUES ($1, $2, $3, $4, $5, $7, $8, NOW())
+			RETURNING "id", "price", "created_at"
+		)
+		INSERT INTO "product_price"("id", "product_id", "price", "effective_at", "applied_at", "created_at")
+		SELECT $6, "id", "price", "created_at", "created_at", "created_at" FROM created`
+
+	_, err = ch.tx.Exec(ctx, query,
 		id,
 		req.Name,
 		req.Price,
 		req.Barcode,
-		req.Category_id,
+		helper.NewNullString(req.Category_id),
+		uuid.NewString(),
+		helper.NewNullString(req.Unit_id),
+		helper.NewNullString(req.Tax_rate_id),
 	)
 
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "product")
 	}
 
 	return id, nil
 
 }
 
-func (c *productRepo) GetProduct(req *models.ProductIdRequest) (resp *models.Product, err error) {
+func (c *productRepo) GetProduct(ctx context.Context, req *models.ProductIdRequest) (resp *models.Product, err error) {
 
 	query := `
 		SELECT
 		   "id", 
 		    "name",
 		    "price",
+			` + productLastCost + `,
 			"barcode",
 			"category_id",
+			` + productUnit + `,
+			` + productTax + `,
 		    "created_at", 
 			"updated_at" 
 		FROM "product"
 		WHERE id = $1
 	`
 	var (
-		createdAt time.Time
-		updatedAt sql.NullTime
+		cost        decimal.NullDecimal
+		category_id sql.NullString
+		unit_id     sql.NullString
+		unit        sql.NullString
+		precision   sql.NullInt32
+		tax_rate_id sql.NullString
+		createdAt   time.Time
+		updatedAt   sql.NullTime
 	)
 
 	Product := models.Product{}
-	err = c.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err = c.db.QueryRow(ctx, query, req.Id).Scan(
 		&Product.ID,
 		&Product.Name,
 		&Product.Price,
+		&cost,
 		&Product.Barcode,
-		&Product.Category_id,
+		&category_id,
+		&unit_id,
+		&unit,
+		&precision,
+		&tax_rate_id,
+		&Product.TaxRate,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("Product not found")
+		return nil, wrapError(err, "product")
 	}
+	Product.Category_id = category_id.String
+	Product.Unit_id = unit_id.String
+	Product.Tax_rate_id = tax_rate_id.String
+	Product.Unit, Product.Precision = unitOf(unit, precision)
+	Product.Cost, Product.Margin, Product.MarginPercent = productMargin(Product.Price, cost)
 	Product.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
 		Product.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -93,143 +186,422 @@ func (c *productRepo) GetProduct(req *models.ProductIdRequest) (resp *models.Pro
 	return &Product, nil
 }
 
-func (c *productRepo) GetProductByBarcode(req *models.CheckBarcodeComingTable) (resp *models.RespBarcodeProduct, err error) {
+// GetProductByBarcode returns the product with req.Barcode at the price of the
+// branch req names.
+func (c *productRepo) GetProductByBarcode(ctx context.Context, req *models.CheckBarcodeComingTable) (resp *models.RespBarcodeProduct, err error) {
 
 	query := `
 		SELECT
 		    "name",
-		    "price",
-			"category_id"
+		    COALESCE((
+				SELECT bp."price"
+				FROM "branch_price" bp
+				WHERE bp."product_id" = "product"."id"
+					AND bp."branch_id" = COALESCE(
+						CAST(NULLIF($2, '') AS uuid),
+						(SELECT ct."branch_id" FROM "coming_table" ct WHERE ct."id" = CAST(NULLIF($3, '') AS uuid))
+					)
+			), "price"),
+			` + productLastCost + `,
+			"category_id",
+			` + productUnit + `,
+			` + productTax + `
 		FROM "product"
-		WHERE barcode = $1 and coming_table_id=$2
+		WHERE barcode = $1
 	`
 
+	var (
+		cost        decimal.NullDecimal
+		category_id sql.NullString
+		unit_id     sql.NullString
+		unit        sql.NullString
+		precision   sql.NullInt32
+		tax_rate_id sql.NullString
+	)
 	Product := models.RespBarcodeProduct{}
-	err = c.db.QueryRow(context.Background(), query, req.Barcode, req.Coming_Table_id).Scan(
+	err = c.db.QueryRow(ctx, query, req.Barcode, req.Branch_id, req.Coming_Table_id).Scan(
 		&Product.Name,
 		&Product.Price,
-		&Product.Category_id,
+		&cost,
+		&category_id,
+		&unit_id,
+		&unit,
+		&precision,
+		&tax_rate_id,
+		&Product.TaxRate,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("Product not found")
+		return nil, wrapError(err, "product")
+	}
+	if cost.Valid {
+		Product.Cost = &cost.Decimal
 	}
+	Product.Category_id = category_id.String
+	Product.Unit_id = unit_id.String
+	Product.Unit, Product.Precision = unitOf(unit, precision)
+	Product.Tax_rate_id = tax_rate_id.String
 
 	return &Product, nil
 }
 
-func (c *productRepo) GetAllProduct(req *models.GetAllProductRequest) (*models.GetAllProductResponse, error) {
-	params := make(map[string]interface{})
+func (c *productRepo) GetAllProduct(ctx context.Context, req *models.GetAllProductRequest) (*models.GetAllProductResponse, error) {
+	page, err := newListPage(req.ListRequest, productSortColumns)
+	if err != nil {
+		return nil, err
+	}
 	var resp = &models.GetAllProductResponse{}
 
 	resp.Products = make([]models.Product, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 		SELECT
-			COUNT(*) OVER(),
+			` + page.columns() + `
 			"id",
 			"name",
-			"price",		
+			"price",
+			` + productLastCost + `,
 			"barcode",
 			"category_id",
+			` + productUnit + `,
+			` + productTax + `,
 			"created_at",
 			"updated_at" 
 		FROM "product"
-	`
+	`)
 	if req.Name != "" {
-		filter += ` AND ("name" ILIKE '%' || :name ) `
-		params["search"] = req.Name
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
 	}
 	if req.Barcode != "" {
-		filter += ` AND ("barcode" ILIKE '%' || :barcode ) `
-		params["search"] = req.Barcode
+		q.Where(`"barcode" = ?`, req.Barcode)
+	}
+	if req.Category_id != "" {
+		q.Where(`"category_id" = ?`, req.Category_id)
+	}
+	if req.Unit_id != "" {
+		q.Where(`"unit_id" = ?`, req.Unit_id)
+	}
+	if req.PriceFrom != nil {
+		q.Where(`"price" >= ?`, *req.PriceFrom)
+	}
+	if req.PriceTo != nil {
+		q.Where(`"price" <= ?`, *req.PriceTo)
 	}
 
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
-	rows, err := c.db.Query(context.Background(), rquery, pArr...)
+	page.apply(q)
+	rquery, args := q.Build()
+	rows, err := c.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "product")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var (
+			sortKey     string
 			id          sql.NullString
 			name        sql.NullString
-			price       sql.NullFloat64
+			price       decimal.NullDecimal
+			cost        decimal.NullDecimal
 			barcode     sql.NullString
 			category_id sql.NullString
+			unit_id     sql.NullString
+			unit        sql.NullString
+			precision   sql.NullInt32
+			tax_rate_id sql.NullString
+			taxRate     decimal.Decimal
 			createdAt   sql.NullString
 			updatedAt   sql.NullString
 		)
 		err := rows.Scan(
 			&resp.Count,
+			&sortKey,
 			&id,
 			&name,
 			&price,
+			&cost,
 			&barcode,
 			&category_id,
+			&unit_id,
+			&unit,
+			&precision,
+			&tax_rate_id,
+			&taxRate,
 			&createdAt,
 			&updatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		resp.Products = append(resp.Products, models.Product{
+		if !page.keep(sortKey, id.String) {
+			break
+		}
+		product := models.Product{
 			ID:          id.String,
 			Name:        name.String,
-			Price:       price.Float64,
+			Price:       price.Decimal,
 			Barcode:     barcode.String,
 			Category_id: category_id.String,
+			Unit_id:     unit_id.String,
+			Tax_rate_id: tax_rate_id.String,
+			TaxRate:     taxRate,
 			CreatedAt:   createdAt.String,
 			UpdatedAt:   updatedAt.String,
-		})
+		}
+		product.Unit, product.Precision = unitOf(unit, precision)
+		product.Cost, product.Margin, product.MarginPercent = productMargin(product.Price, cost)
+		resp.Products = append(resp.Products, product)
 	}
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
 
-func (c *productRepo) UpdateProduct(req *models.UpdateProduct) (string, error) {
+// UpdateProduct overwrites the product. A changed price is recorded in the
+// price history in the same statement.
+func (c *productRepo) UpdateProduct(ctx context.Context, req *models.UpdateProduct) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "product", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
 
 	query := `
-		UPDATE
-			"product"
-		SET
-			"name" = $1,
-			"price" = $2,
-			"barcode" = $3,
-			"category_id" = $4,
-			"updated_at" = NOW()
-			WHERE id= $5 RETURNING id	`
-
-	result, err := c.db.Exec(context.Background(), query, req.Name, req.Price, req.Barcode, req.Category_id, req.ID)
+		WITH old AS (
+			SELECT "id", "price" FROM "product" WHERE "id" = $5 FOR UPDATE
+		), updated AS (
+			UPDATE
+				"product" p
+			SET
+				"name" = $1,
+				"price" = $2,
+				"barcode" = $3,
+				"category_id" = $4,
+				"unit_id" = $7,
+				"tax_rate_id" = $8,
+				"updated_at" = NOW()
+			FROM old
+			WHERE p."id" = old."id"
+			RETURNING p."id", old."price" AS "old_price", p."price"
+		), history AS (
+			INSERT INTO "product_price"("id", "product_id", "old_price", "price", "effective_at", "applied_at", "created_at")
+			SELECT $6, "id", "old_price", "price", NOW(), NOW(), NOW() FROM updated
+			WHERE "old_price" <> "price"
+		)
+		SELECT COUNT(*) FROM updated`
+
+	var count int
+	err = ch.tx.QueryRow(ctx, query, req.Name, req.Price, req.Barcode, helper.NewNullString(req.Category_id), req.ID, uuid.NewString(), helper.NewNullString(req.Unit_id), helper.NewNullString(req.Tax_rate_id)).Scan(&count)
 	if err != nil {
-		return "Error Update Product", err
+		return "", wrapError(err, "product")
 	}
 
-	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Product not found")
+	if count == 0 {
+		return "", notFound("product")
 	}
 
 	return req.ID, nil
 }
 
-func (c *productRepo) DeleteProduct(req *models.ProductIdRequest) (resp string, err error) {
+func (c *productRepo) DeleteProduct(ctx context.Context, req *models.ProductIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "product", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
 	query := `DELETE FROM product 
 	            WHERE id = $1 RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id)
 	if err != nil {
-		return "Error from Delete Product", err
+		return "", wrapError(err, "product")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Product not found")
+		return "", notFound("product")
 	}
 
 	return req.Id, nil
 }
+
+// SearchProduct ranks products by full-text match of the query against name,
+// barcode and category name, plus trigram similarity so that misspelled names
+// still match. Category matches weigh half as much as product matches.
+func (c *productRepo) SearchProduct(ctx context.Context, req *models.SearchProductRequest) ([]models.ProductSearchResult, error) {
+	q := query.Select(`
+		SELECT
+			p."id",
+			p."name",
+			p."price",
+			p."barcode",
+			p."category_id",
+			COALESCE(g."name", ''),
+			ts_rank(to_tsvector('simple', p."name" || ' ' || p."barcode"), s.tsq)
+				+ ts_rank(to_tsvector('simple', COALESCE(g."name", '')), s.tsq) / 2
+				+ GREATEST(
+					word_similarity(s.term, p."name"),
+					similarity(s.term, p."barcode"),
+					word_similarity(s.term, COALESCE(g."name", '')) / 2
+				) AS "rank",
+			ts_headline('simple', p."name", s.tsq, 'StartSel=<b>, StopSel=</b>, HighlightAll=true'),
+			ts_headline('simple', COALESCE(g."name", ''), s.tsq, 'StartSel=<b>, StopSel=</b>, HighlightAll=true')
+		FROM (SELECT websearch_to_tsquery('simple', ?) AS tsq, CAST(? AS text) AS term) s
+		CROSS JOIN "product" p
+		LEFT JOIN "category" g ON g."id" = p."category_id"
+	`, req.Query, req.Query)
+
+	q.Where(`to_tsvector('simple', p."name" || ' ' || p."barcode") @@ s.tsq
+		OR s.term <% p."name"
+		OR p."barcode" LIKE s.term || '%'
+		OR to_tsvector('simple', COALESCE(g."name", '')) @@ s.tsq
+		OR s.term <% g."name"`)
+	if req.Category_id != "" {
+		q.Where(`p."category_id" = ?`, req.Category_id)
+	}
+	q.OrderBy(`"rank" DESC`, `p."name"`).Limit(req.Limit)
+	rquery, args := q.Build()
+
+	rows, err := c.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "product")
+	}
+	defer rows.Close()
+
+	resp := make([]models.ProductSearchResult, 0)
+	for rows.Next() {
+		var (
+			product     models.ProductSearchResult
+			category_id sql.NullString
+		)
+		err := rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Price,
+			&product.Barcode,
+			&category_id,
+			&product.CategoryName,
+			&product.Rank,
+			&product.Highlight.Name,
+			&product.Highlight.CategoryName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		product.Category_id = category_id.String
+		resp = append(resp, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "product")
+	}
+
+	return resp, nil
+}
+
+// UpsertProduct creates the product or, when its barcode is taken, updates
+// the existing one. An empty category, unit or tax rate keeps the current
+// one. A new or changed price is recorded in the price history. It reports
+// whether the product was created.
+func (c *productRepo) UpsertProduct(ctx context.Context, req *models.CreateProduct) (id string, created bool, err error) {
+	ch, err := beginChange(ctx, c.db, "product", models.AuditCreate, "")
+	if err != nil {
+		return "", false, err
+	}
+	defer func() { err = ch.end(ctx, id, err) }()
+
+	// The insert waits for a concurrent one of the same barcode to finish, so
+	// a product it does not create exists and is locked before it is noted
+	// for the audit log. The initial price opens the price history, as in
+	// CreateProduct.
+	query := `
+		WITH created AS (
+			INSERT INTO "product"("id", "name", "price", "barcode", "category_id", "unit_id", "tax_rate_id", "created_at")
+			VALUES ($1, $2, $3, $4, $5, $7, $8, NOW())
+			ON CONFLICT ("barcode") DO NOTHING
+			RETURNING "id", "price", "created_at"
+		), history AS (
+			INSERT INTO "product_price"("id", "product_id", "price", "effective_at", "applied_at", "created_at")
+			SELECT $6, "id", "price", "created_at", "created_at", "created_at" FROM created
+		)
+		SELECT "id" FROM created
+	`
+
+	err = ch.tx.QueryRow(ctx, query,
+		uuid.NewString(),
+		req.Name,
+		req.Price,
+		req.Barcode,
+		helper.NewNullString(req.Category_id),
+		uuid.NewString(),
+		helper.NewNullString(req.Unit_id),
+		helper.NewNullString(req.Tax_rate_id),
+	).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", false, wrapError(err, "product")
+	}
+
+	err = ch.tx.QueryRow(ctx, `SELECT "id" FROM "product" WHERE "barcode" = $1 FOR UPDATE`, req.Barcode).Scan(&id)
+	if err != nil {
+		return "", false, wrapError(err, "product")
+	}
+	if err := ch.update(ctx, id); err != nil {
+		return "", false, err
+	}
+
+	query = `
+		WITH old AS (
+			SELECT "price" FROM "product" WHERE "id" = $1
+		), updated AS (
+			UPDATE "product" SET
+				"name" = $2,
+				"price" = $3,
+				"category_id" = COALESCE($4, "category_id"),
+				"unit_id" = COALESCE($5, "unit_id"),
+				"tax_rate_id" = COALESCE($6, "tax_rate_id"),
+				"updated_at" = NOW()
+			WHERE "id" = $1
+			RETURNING "id", "price"
+		)
+		INSERT INTO "product_price"("id", "product_id", "old_price", "price", "effective_at", "applied_at", "created_at")
+		SELECT $7, u."id", o."price", u."price", NOW(), NOW(), NOW()
+		FROM updated u, old o
+		WHERE o."price" IS DISTINCT FROM u."price"
+	`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Name,
+		req.Price,
+		helper.NewNullString(req.Category_id),
+		helper.NewNullString(req.Unit_id),
+		helper.NewNullString(req.Tax_rate_id),
+		uuid.NewString(),
+	)
+	if err != nil {
+		return "", false, wrapError(err, "product")
+	}
+
+	return id, false, nil
+}
+
+// productMargin returns the cost, margin and margin percent of a product sold
+// at price, all nil while its cost is unknown.
+func productMargin(price decimal.Decimal, cost decimal.NullDecimal) (*decimal.Decimal, *decimal.Decimal, *decimal.Decimal) {
+	if !cost.Valid {
+		return nil, nil, nil
+	}
+	m, percent := margin(price, cost.Decimal)
+	return &cost.Decimal, &m, &percent
+}
+
+// margin returns the margin of a unit bought at cost and sold at price, and
+// that margin as a percentage of the price rounded to hundredths.
+func margin(price, cost decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	m := price.Sub(cost)
+	if price.IsZero() {
+		return m, decimal.Zero
+	}
+	return m, m.Mul(decimal.NewFromInt(100)).DivRound(price, 2)
+}
@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// productPriceSortColumns are the fields the list may be sorted by.
+var productPriceSortColumns = map[string]sortColumn{
+	"price":        {Name: "price", Type: "numeric"},
+	"effective_at": {Name: "effective_at", Type: "timestamp"},
+	"created_at":   {Name: "created_at", Type: "timestamp"},
+}
+
+const productPriceColumns = `
+	"id",
+	"product_id",
+	(SELECT "name" FROM "product" WHERE "product"."id" = "product_price"."product_id"),
+	"old_price",
+	"price",
+	"effective_at",
+	"applied_at",
+	"created_at"`
+
+type productPriceRepo struct {
+	db dbtx
+}
+
+func NewProductPriceRepo(db dbtx) *productPriceRepo {
+	return &productPriceRepo{
+		db: db,
+	}
+}
+
+// CreateProductPrice schedules a price change. It only becomes the product
+// price once ApplyDuePrices runs after its effective time.
+func (r *productPriceRepo) CreateProductPrice(ctx context.Context, req *models.CreateProductPrice) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "product_price", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	query := `
+		INSERT INTO "product_price"("id", "product_id", "price", "effective_at", "created_at")
+		SELECT $1, "id", $3, COALESCE(CAST(CAST($4 AS text) AS timestamp), NOW()), NOW()
+		FROM "product"
+		WHERE "id" = $2
+		RETURNING "id"`
+
+	var id string
+	err = ch.tx.QueryRow(ctx, query,
+		uuid.NewString(),
+		req.ProductID,
+		req.Price,
+		helper.NewNullString(req.EffectiveAt),
+	).Scan(&id)
+	if err != nil {
+		return "", wrapError(err, "product")
+	}
+
+	return id, nil
+}
+
+func (r *productPriceRepo) GetProductPrice(ctx context.Context, req *models.ProductPriceIdRequest) (*models.ProductPrice, error) {
+	query := `SELECT ` + productPriceColumns + ` FROM "product_price" WHERE "id" = $1`
+
+	price, err := scanProductPrice(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "product price")
+	}
+
+	return price, nil
+}
+
+func (r *productPriceRepo) GetAllProductPrice(ctx context.Context, req *models.GetAllProductPriceRequest) (*models.GetAllProductPriceResponse, error) {
+	page, err := newListPage(req.ListRequest, productPriceSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllProductPriceResponse{}
+
+	resp.ProductPrices = make([]models.ProductPrice, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + productPriceColumns + `
+			FROM "product_price"
+		`)
+	if req.ProductID != "" {
+		q.Where(`"product_id" = ?`, req.ProductID)
+	}
+	switch req.Status {
+	case models.PriceScheduled:
+		q.Where(`"applied_at" IS NULL`)
+	case models.PriceApplied:
+		q.Where(`"applied_at" IS NOT NULL`)
+	}
+	if req.DateFrom != "" {
+		q.Where(`"effective_at" >= ?::date`, req.DateFrom)
+	}
+	if req.DateTo != "" {
+		q.Where(`"effective_at" < ?::date + 1`, req.DateTo)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "product price")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		price, err := scanProductPrice(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, price.ID) {
+			break
+		}
+		resp.ProductPrices = append(resp.ProductPrices, *price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "product price")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// DeleteProductPrice cancels a scheduled price change. Applied changes are
+// history and cannot be deleted.
+func (r *productPriceRepo) DeleteProductPrice(ctx context.Context, req *models.ProductPriceIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "product_price", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `
+		WITH target AS (
+			SELECT "id", "applied_at" FROM "product_price" WHERE "id" = $1 FOR UPDATE
+		), deleted AS (
+			DELETE FROM "product_price" pp
+			USING target
+			WHERE pp."id" = target."id" AND target."applied_at" IS NULL
+			RETURNING pp."id"
+		)
+		SELECT (SELECT COUNT(*) FROM target), (SELECT COUNT(*) FROM deleted)`
+
+	var found, deleted int
+	if err := ch.tx.QueryRow(ctx, query, req.Id).Scan(&found, &deleted); err != nil {
+		return "", wrapError(err, "product price")
+	}
+
+	if found == 0 {
+		return "", notFound("product price")
+	}
+	if deleted == 0 {
+		return "", storage.NewError(storage.ErrInvalidState, "price change already applied", nil)
+	}
+
+	return req.Id, nil
+}
+
+// ApplyDuePrices makes every scheduled price change whose effective time has
+// passed the product price and returns how many it applied. Changes of one
+// product are applied in effective order, one statement each, so that
+// concurrent callers never apply them out of order or twice. Each is logged
+// as an update of the product without an actor.
+func (r *productPriceRepo) ApplyDuePrices(ctx context.Context) (int, error) {
+	query := `
+		WITH due AS (
+			SELECT pp."id", pp."product_id", pp."price"
+			FROM "product_price" pp
+			WHERE pp."applied_at" IS NULL
+				AND pp."effective_at" <= NOW()
+				AND NOT EXISTS (
+					SELECT 1 FROM "product_price" e
+					WHERE e."product_id" = pp."product_id"
+						AND e."applied_at" IS NULL
+						AND (e."effective_at", e."created_at") < (pp."effective_at", pp."created_at")
+				)
+			ORDER BY pp."effective_at", pp."created_at"
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		), old AS (
+			SELECT p."id", p."price", to_jsonb(p) AS "row"
+			FROM "product" p
+			JOIN due ON due."product_id" = p."id"
+			FOR UPDATE OF p
+		), updated AS (
+			UPDATE "product" p
+			SET "price" = due."price", "updated_at" = NOW()
+			FROM due
+			WHERE p."id" = due."product_id"
+			RETURNING p."id", to_jsonb(p) AS "row"
+		), logged AS (
+			INSERT INTO "audit_log"("id", "entity_type", "entity_id", "action", "before", "after", "created_at")
+			SELECT gen_random_uuid(), 'product', u."id", 'update', old."row", u."row", NOW()
+			FROM updated u
+			JOIN old ON old."id" = u."id"
+		)
+		UPDATE "product_price" pp
+		SET "applied_at" = NOW(), "old_price" = old."price"
+		FROM due
+		JOIN old ON old."id" = due."product_id"
+		WHERE pp."id" = due."id"`
+
+	applied := 0
+	for {
+		result, err := r.db.Exec(ctx, query)
+		if err != nil {
+			return applied, wrapError(err, "product price")
+		}
+		if result.RowsAffected() == 0 {
+			return applied, nil
+		}
+		applied++
+	}
+}
+
+// scanProductPrice scans productPriceColumns, after the leading columns of a
+// list query when lead is given.
+func scanProductPrice(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.ProductPrice, error) {
+	var (
+		price       models.ProductPrice
+		productName sql.NullString
+		oldPrice    decimal.NullDecimal
+		effectiveAt time.Time
+		appliedAt   sql.NullTime
+		createdAt   time.Time
+	)
+	err := row.Scan(append(lead,
+		&price.ID,
+		&price.ProductID,
+		&productName,
+		&oldPrice,
+		&price.Price,
+		&effectiveAt,
+		&appliedAt,
+		&createdAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	price.ProductName = productName.String
+	if oldPrice.Valid {
+		price.OldPrice = &oldPrice.Decimal
+	}
+	price.Status = models.PriceScheduled
+	if appliedAt.Valid {
+		price.Status = models.PriceApplied
+		price.AppliedAt = appliedAt.Time.Format(time.RFC3339)
+	}
+	price.EffectiveAt = effectiveAt.Format(time.RFC3339)
+	price.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &price, nil
+}
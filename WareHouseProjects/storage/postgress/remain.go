@@ -3,62 +3,115 @@ package postgres
 import (
 	"WareHouseProjects/models"
 	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
 )
 
+// remainSortColumns are the fields the list may be sorted by.
+var remainSortColumns = map[string]sortColumn{
+	"name":        {Name: "name", Type: "text"},
+	"price":       {Name: "price", Type: "numeric"},
+	"cost":        {Name: "cost", Type: "numeric"},
+	"barcode":     {Name: "barcode", Type: "text"},
+	"count":       {Name: "count", Type: "numeric"},
+	"total_price": {Name: "total_price", Type: "numeric"},
+	"created_at":  {Name: "created_at", Type: "timestamp"},
+}
+
+// remainingSource replaces the "remaining" table in reads. Its price is the
+// current selling price of the product in the branch of the stock rather than
+// the one copied in at the last income; "unit" and "precision" are those of
+// the product.
+const remainingSource = `(
+	SELECT
+		r."id",
+		r."branch_id",
+		r."category_id",
+		r."name",
+		COALESCE(bp."price", p."price", r."price") AS "price",
+		r."cost",
+		r."barcode",
+		r."count",
+		u."short_name" AS "unit",
+		u."precision",
+		r."total_price",
+		r."created_at",
+		r."updated_at"
+	FROM "remaining" r
+	LEFT JOIN "product" p ON p."barcode" = r."barcode"
+	LEFT JOIN "unit" u ON u."id" = p."unit_id"
+	LEFT JOIN "branch_price" bp ON bp."product_id" = p."id" AND bp."branch_id" = r."branch_id"
+) "remaining"`
+
 type remainRepo struct {
-	db *pgxpool.Pool
+	db dbtx
 }
 
-func NewRemainRepo(db *pgxpool.Pool) *remainRepo {
+func NewRemainRepo(db dbtx) *remainRepo {
 	return &remainRepo{
 		db: db,
 	}
 }
 
-func (c *remainRepo) CreateRemain(req *models.CreateRemain) (string, error) {
+func (c *remainRepo) CreateRemain(ctx context.Context, req *models.CreateRemain) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "remain", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
 	var (
 		id = uuid.NewString()
 	)
+	if err := checkBranchScope(ctx, req.Branch_id, "remaining"); err != nil {
+		return "", err
+	}
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
 
 	query := `
-		INSERT INTO "remain"(
+		INSERT INTO "remaining"(
 			"id", 
 			"branch_id",
 			"category_id",
 			"name",
 			"price",
+			"cost",
 			"barcode",
 			"count",
 			"total_price",
 			"created_at" )
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())`
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())`
 
-	_, err := c.db.Exec(context.Background(), query,
+	_, err = ch.tx.Exec(ctx, query,
 		id,
 		req.Branch_id,
-		req.Category_id,
+		helper.NewNullString(req.Category_id),
 		req.Name,
 		req.Price,
+		req.Cost,
 		req.Barcode,
 		req.Count,
 		req.TotalPrice,
 	)
 
 	if err != nil {
-		return "", err
+		return "", wrapError(err, "remaining")
 	}
 
 	return id, nil
 }
 
-func (c *remainRepo) GetRemain(req *models.RemainIdRequest) (*models.Remain, error) {
+func (c *remainRepo) GetRemain(ctx context.Context, req *models.RemainIdRequest) (*models.Remain, error) {
 	query := `
 		SELECT
 		    "id",
@@ -66,37 +119,49 @@ func (c *remainRepo) GetRemain(req *models.RemainIdRequest) (*models.Remain, err
 		    "category_id",
 		    "name",
 		    "price",
+		    "cost",
 		    "barcode",
 		    "count",
+		    "unit",
+		    "precision",
 		    "total_price",
 		    "created_at",
 			   "updated_at"
-		FROM "remain"
-		WHERE id = $1
+		FROM ` + remainingSource + `
+		WHERE id = $1 AND ($2::uuid IS NULL OR "branch_id" = $2)
 	`
 	var (
-		createdAt  time.Time
-		updatedAt  sql.NullTime
-		totalPrice float64
+		category_id sql.NullString
+		unit        sql.NullString
+		precision   sql.NullInt32
+		createdAt   time.Time
+		updatedAt   sql.NullTime
+		totalPrice  decimal.Decimal
 	)
 
 	rem := models.Remain{}
-	err := c.db.QueryRow(context.Background(), query, req.Id).Scan(
+	err := c.db.QueryRow(ctx, query, req.Id, branchScope(ctx)).Scan(
 		&rem.ID,
 		&rem.Branch_id,
-		&rem.Category_id,
+		&category_id,
 		&rem.Name,
 		&rem.Price,
+		&rem.Cost,
 		&rem.Barcode,
 		&rem.Count,
+		&unit,
+		&precision,
 		&totalPrice,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("Remain not found")
+		return nil, wrapError(err, "remaining")
 	}
+	rem.Category_id = category_id.String
+	rem.Unit, rem.Precision = unitOf(unit, precision)
 	rem.TotalPrice = totalPrice
+	remainMargin(&rem)
 	rem.CreatedAt = createdAt.Format(time.RFC3339)
 	if updatedAt.Valid {
 		rem.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -105,75 +170,92 @@ func (c *remainRepo) GetRemain(req *models.RemainIdRequest) (*models.Remain, err
 	return &rem, nil
 }
 
-func (c *remainRepo) GetAllRemain(req *models.GetAllRemainRequest) (*models.GetAllRemainResponse, error) {
-	params := make(map[string]interface{})
+func (c *remainRepo) GetAllRemain(ctx context.Context, req *models.GetAllRemainRequest) (*models.GetAllRemainResponse, error) {
+	page, err := newListPage(req.ListRequest, remainSortColumns)
+	if err != nil {
+		return nil, err
+	}
 	resp := &models.GetAllRemainResponse{}
 
 	resp.Remainings = make([]models.Remain, 0)
 
-	filter := " WHERE true "
-	query := `
+	q := query.Select(`
 		SELECT
-			COUNT(*) OVER(),
+			` + page.columns() + `
 			"id",
 			"branch_id",
 			"category_id",
 			"name",
 			"price",
+			"cost",
 			"barcode",
 			"count",
+			"unit",
+			"precision",
 			"total_price",
 			"created_at",
 			"updated_at"
-		FROM "remain"
-	`
-	if req.Category_id != "" {
-		filter += ` AND ("category_id" ILIKE '%' || :search ) `
-		params["search"] = req.Category_id
+		FROM ` + remainingSource + `
+	`)
+	if scope := storage.BranchScope(ctx); scope != "" {
+		q.Where(`"branch_id" = ?`, scope)
 	}
-
 	if req.Branch_id != "" {
-		filter += ` AND ("branch_id" ILIKE '%' || :search ) `
-		params["search"] = req.Branch_id
+		q.Where(`"branch_id" = ?`, req.Branch_id)
+	}
+	if req.Category_id != "" {
+		q.Where(`"category_id" = ?`, req.Category_id)
+	}
+	if req.Product_id != "" {
+		q.Where(`"barcode" = (SELECT "barcode" FROM "product" WHERE "id" = ?)`, req.Product_id)
 	}
-
 	if req.Barcode != "" {
-		filter += ` AND ("barcode" ILIKE '%' || :search ) `
-		params["search"] = req.Barcode
+		q.Where(`"barcode" = ?`, req.Barcode)
+	}
+	if req.Name != "" {
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
+	}
+	if req.PriceFrom != nil {
+		q.Where(`"price" >= ?`, *req.PriceFrom)
+	}
+	if req.PriceTo != nil {
+		q.Where(`"price" <= ?`, *req.PriceTo)
 	}
 
-	offset := (req.Page - 1) * req.Limit
-	params["limit"] = req.Limit
-	params["offset"] = offset
-
-	query = query + filter + " ORDER BY created_at DESC OFFSET :offset LIMIT :limit "
-	rquery, pArr := helper.ReplaceQueryParams(query, params)
+	page.apply(q)
+	rquery, args := q.Build()
 
-	rows, err := c.db.Query(context.Background(), rquery, pArr...)
+	rows, err := c.db.Query(ctx, rquery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, wrapError(err, "remaining")
 	}
 	defer rows.Close()
 
 	var (
-		totalPrice float64
-		createdAt  time.Time
-		updatedAt  sql.NullTime
+		sortKey     string
+		category_id sql.NullString
+		unit        sql.NullString
+		precision   sql.NullInt32
+		totalPrice  decimal.Decimal
+		createdAt   time.Time
+		updatedAt   sql.NullTime
 	)
 
-	count := 0
 	for rows.Next() {
 		var rem models.Remain
-		count++
 		err := rows.Scan(
-			&count,
+			&resp.Count,
+			&sortKey,
 			&rem.ID,
 			&rem.Branch_id,
-			&rem.Category_id,
+			&category_id,
 			&rem.Name,
 			&rem.Price,
+			&rem.Cost,
 			&rem.Barcode,
 			&rem.Count,
+			&unit,
+			&precision,
 			&totalPrice,
 			&createdAt,
 			&updatedAt,
@@ -181,7 +263,13 @@ func (c *remainRepo) GetAllRemain(req *models.GetAllRemainRequest) (*models.GetA
 		if err != nil {
 			return nil, err
 		}
+		if !page.keep(sortKey, rem.ID) {
+			break
+		}
+		rem.Category_id = category_id.String
+		rem.Unit, rem.Precision = unitOf(unit, precision)
 		rem.TotalPrice = totalPrice
+		remainMargin(&rem)
 		rem.CreatedAt = createdAt.Format(time.RFC3339)
 		if updatedAt.Valid {
 			rem.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
@@ -189,139 +277,166 @@ func (c *remainRepo) GetAllRemain(req *models.GetAllRemainRequest) (*models.GetA
 		resp.Remainings = append(resp.Remainings, rem)
 	}
 
-	resp.Count = count
+	resp.NextCursor = page.nextCursor()
+
 	return resp, nil
 }
-func (c *remainRepo) UpdateRemain(req *models.UpdateRemain) (string, error) {
-	totalPrice := req.Count * req.Price
 
-	query := `UPDATE remain 
+// UpdateRemain overwrites the stock. Without a cost it keeps its current one;
+// the total is recomputed at cost.
+func (c *remainRepo) UpdateRemain(ctx context.Context, req *models.UpdateRemain) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "remain", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	if err := checkBranchScope(ctx, req.Branch_id, "remaining"); err != nil {
+		return "", err
+	}
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
+
+	query := `UPDATE remaining 
 	            SET  branch_id = $1, 
 				     category_id = $2,
 					 name=$3,
 					 price=$4,
-					 barcode=$5,
-					 count=$6,
-					 total_price=$7, 
+					 cost=COALESCE(NULLIF($5::numeric, 0), cost),
+					 barcode=$6,
+					 count=$7,
+					 total_price=$7 * COALESCE(NULLIF($5::numeric, 0), cost), 
 					 updated_at = NOW() 
-					 WHERE id = $8 RETURNING id`
+					 WHERE id = $8 AND ($9::uuid IS NULL OR branch_id = $9) RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Branch_id, req.Category_id, req.Name, req.Price, req.Barcode, req.Count, totalPrice, req.ID)
+	result, err := ch.tx.Exec(ctx, query, req.Branch_id, helper.NewNullString(req.Category_id), req.Name, req.Price, req.Cost, req.Barcode, req.Count, req.ID, branchScope(ctx))
 	if err != nil {
-		return "Error Update Remain", err
+		return "", wrapError(err, "remaining")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Remain not found")
+		return "", notFound("remaining")
 	}
 
 	return req.ID, nil
 }
 
-func (c *remainRepo) DeleteRemain(req *models.RemainIdRequest) (resp string, err error) {
-	query := `DELETE FROM remain 
-	            WHERE id = $1 RETURNING id`
+func (c *remainRepo) DeleteRemain(ctx context.Context, req *models.RemainIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, c.db, "remain", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM remaining 
+	            WHERE id = $1 AND ($2::uuid IS NULL OR branch_id = $2) RETURNING id`
 
-	result, err := c.db.Exec(context.Background(), query, req.Id)
+	result, err := ch.tx.Exec(ctx, query, req.Id, branchScope(ctx))
 	if err != nil {
-		return "Error from Delete Remain", err
+		return "", wrapError(err, "remaining")
 	}
 
 	if result.RowsAffected() == 0 {
-		return "", fmt.Errorf("Remain not found")
+		return "", notFound("remaining")
 	}
 
 	return req.Id, nil
 }
 
-func (c *remainRepo) CheckRemain(req *models.CheckRemain) (string, error) {
-	var id sql.NullString
-	var params map[string]interface{}
-
-	query := `
-		SELECT
-			"id"
-		FROM "remaining"
-		WHERE "branch_id" = :branch_id AND "barcode" = :barcode
-	`
-
-	params = map[string]interface{}{
-		"branch_id": req.Branch_id,
-		"barcode":   req.Barcode,
+// AddRemain adds req.Count units at req.TotalPrice to the stock of the
+// barcode in the branch, creating the stock when there is none. The stock cost
+// becomes the average cost of all its units, or req.Cost when nothing is left
+// in stock. It returns the id of the stock.
+func (c *remainRepo) AddRemain(ctx context.Context, req *models.CreateRemain) (id string, err error) {
+	if err := checkBranchScope(ctx, req.Branch_id, "remaining"); err != nil {
+		return "", err
 	}
-	queryN, args := helper.ReplaceQueryParams(query, params)
 
-	err := c.db.QueryRow(context.Background(), queryN, args...).Scan(
-		&id,
-	)
+	ch, err := beginChange(ctx, c.db, "remain", models.AuditCreate, "")
 	if err != nil {
-		return id.String, err
+		return "", err
 	}
+	defer func() { err = ch.end(ctx, id, err) }()
 
-	return id.String, nil
-}
+	if err := checkCount(ctx, ch.tx, req.Barcode, req.Count); err != nil {
+		return "", err
+	}
 
-func (c *remainRepo) UpdateIdAviable(req *models.UpdateRemain) (string, error) {
-	query := `UPDATE  remain SET
-	                 "branch_id" = $1,
-	                 "category_id" = $2,
-	                 "name" = $3,
-	                 "price" = $4,
-	                 "barcode" =$5,
-	                 "count" = "count" + $6,
-                	 "total_price" = "total_price" + $7,
-	                 "updated_at" = NOW()
-                    WHERE id = $8    `
-
-	resp, err := c.db.Exec(context.Background(), query,
+	// The insert waits for a concurrent one of the same stock to finish, so
+	// stock it does not create exists and is locked before it is noted for
+	// the audit log.
+	query := `
+		INSERT INTO "remaining"(
+			"id",
+			"branch_id",
+			"category_id",
+			"name",
+			"price",
+			"cost",
+			"barcode",
+			"count",
+			"total_price",
+			"created_at" )
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT ("branch_id", "barcode") DO NOTHING
+		RETURNING "id"`
+
+	err = ch.tx.QueryRow(ctx, query,
+		uuid.NewString(),
 		req.Branch_id,
-		req.Category_id,
+		helper.NewNullString(req.Category_id),
 		req.Name,
 		req.Price,
+		req.Cost,
 		req.Barcode,
 		req.Count,
 		req.TotalPrice,
-		req.ID,
-	)
-	if err != nil {
-		return "", err
+	).Scan(&id)
+	if err == nil {
+		return id, nil
 	}
-
-	if resp.RowsAffected() == 0 {
-		return "", fmt.Errorf("remaining with ID %s not found", req.ID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", wrapError(err, "remaining")
 	}
 
-	return req.ID, nil
-}
+	err = ch.tx.QueryRow(ctx, `SELECT "id" FROM "remaining" WHERE "branch_id" = $1 AND "barcode" = $2 FOR UPDATE`, req.Branch_id, req.Barcode).Scan(&id)
+	if err != nil {
+		return "", wrapError(err, "remaining")
+	}
+	if err := ch.update(ctx, id); err != nil {
+		return "", err
+	}
 
-func (c *coming_TableProductRepo) GetComingTableById(req *models.ComingTableProductIdRequest) (*models.ComingTableProduct, error) {
-	query := `
-	SELECT
-		"id",
-		"category_id",
-		"name",
-		"price",
-		"barcode",
-		sum("count"),
-		sum("total_price"),
-	FROM "remain"
-	WHERE coming_table_id = $1
-	GROUPD BY id,barcode
-`
-
-	rem := models.ComingTableProduct{}
-	err := c.db.QueryRow(context.Background(), query, req.Id).Scan(
-		&rem.ID,
-		&rem.Category_id,
-		&rem.Name,
-		&rem.Price,
-		&rem.Barcode,
-		&rem.Count,
-		&rem.TotalPrice,
+	query = `
+		UPDATE "remaining" SET
+			"category_id" = $2,
+			"name" = $3,
+			"price" = $4,
+			"cost" = CASE WHEN "count" + $6 > 0 THEN ("total_price" + $7) / ("count" + $6) ELSE $5 END,
+			"count" = "count" + $6,
+			"total_price" = "total_price" + $7,
+			"updated_at" = NOW()
+		WHERE "id" = $1`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		helper.NewNullString(req.Category_id),
+		req.Name,
+		req.Price,
+		req.Cost,
+		req.Count,
+		req.TotalPrice,
 	)
 	if err != nil {
-		return nil, fmt.Errorf(" Error in GetComingTableById func")
+		return "", wrapError(err, "remaining")
 	}
 
-	return &rem, nil
+	return id, nil
+}
+
+// remainMargin fills the margins of rem from its price and cost.
+func remainMargin(rem *models.Remain) {
+	rem.Margin, rem.MarginPercent = margin(rem.Price, rem.Cost)
+	rem.TotalMargin = rem.Margin.Mul(rem.Count)
 }
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"testing"
+)
+
+func TestMargin(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       string
+		cost        string
+		wantMargin  string
+		wantPercent string
+	}{
+		{"tenths", "0.3", "0.1", "0.2", "66.67"},
+		{"exact percent", "12.5", "10", "2.5", "20"},
+		{"loss", "0.1", "0.3", "-0.2", "-200"},
+		{"free", "0", "0.2", "-0.2", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, percent := margin(dec(tt.price), dec(tt.cost))
+			if m.String() != tt.wantMargin {
+				t.Errorf("margin = %s, want %s", m, tt.wantMargin)
+			}
+			if percent.String() != tt.wantPercent {
+				t.Errorf("percent = %s, want %s", percent, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestAddRemainAverageCost(t *testing.T) {
+	ctx, s := testStore(t)
+
+	branchID, err := s.Branch().CreateBranch(ctx, &models.CreateBranch{Name: "North", Code: "NRT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id string
+	for _, add := range []struct{ count, cost, total string }{
+		{"3", "0.1", "0.3"},
+		{"3", "0.2", "0.6"},
+		{"2", "0.3", "0.6"},
+	} {
+		got, err := s.Remaining().AddRemain(ctx, &models.CreateRemain{
+			Branch_id:  branchID,
+			Name:       "Milk",
+			Barcode:    "1001",
+			Price:      dec("1"),
+			Cost:       dec(add.cost),
+			Count:      dec(add.count),
+			TotalPrice: dec(add.total),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "" && got != id {
+			t.Fatalf("income went to stock %s, want %s", got, id)
+		}
+		id = got
+	}
+
+	rem, err := s.Remaining().GetRemain(ctx, &models.RemainIdRequest{Id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"count", rem.Count.String(), "8"},
+		{"total", rem.TotalPrice.String(), "1.5"},
+		{"cost", rem.Cost.String(), "0.1875"},
+		{"margin", rem.Margin.String(), "0.8125"},
+		{"total margin", rem.TotalMargin.String(), "6.5"},
+	} {
+		if !dec(c.got).Equal(dec(c.want)) {
+			t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+		}
+	}
+}
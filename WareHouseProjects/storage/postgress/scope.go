@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/storage"
+	"context"
+	"database/sql"
+)
+
+// branchScope is the branch ctx is limited to as a query argument, null when
+// it is not. Queries match it with ($n::uuid IS NULL OR "branch_id" = $n).
+func branchScope(ctx context.Context) sql.NullString {
+	return helper.NewNullString(storage.BranchScope(ctx))
+}
+
+// checkBranchScope forbids storing entity in branchID when ctx is limited to
+// another branch.
+func checkBranchScope(ctx context.Context, branchID, entity string) error {
+	if scope := storage.BranchScope(ctx); scope != "" && scope != branchID {
+		return storage.NewError(storage.ErrForbidden, entity+" belongs to another branch", nil)
+	}
+	return nil
+}
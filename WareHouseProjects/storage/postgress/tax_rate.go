@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// taxRateSortColumns are the fields the list may be sorted by.
+var taxRateSortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"rate":       {Name: "rate", Type: "numeric"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const taxRateColumns = `
+	"id",
+	"name",
+	"rate",
+	"created_at",
+	"updated_at"`
+
+type taxRateRepo struct {
+	db dbtx
+}
+
+func NewTaxRateRepo(db dbtx) *taxRateRepo {
+	return &taxRateRepo{
+		db: db,
+	}
+}
+
+func (r *taxRateRepo) CreateTaxRate(ctx context.Context, req *models.CreateTaxRate) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "tax_rate", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "tax_rate"(
+			"id",
+			"name",
+			"rate",
+			"created_at")
+		VALUES ($1, $2, $3, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Name,
+		req.Rate,
+	)
+	if err != nil {
+		return "", wrapError(err, "tax rate")
+	}
+
+	return id, nil
+}
+
+func (r *taxRateRepo) GetTaxRate(ctx context.Context, req *models.TaxRateIdRequest) (*models.TaxRate, error) {
+	query := `SELECT ` + taxRateColumns + ` FROM "tax_rate" WHERE "id" = $1`
+
+	rate, err := scanTaxRate(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "tax rate")
+	}
+
+	return rate, nil
+}
+
+func (r *taxRateRepo) GetAllTaxRate(ctx context.Context, req *models.GetAllTaxRateRequest) (*models.GetAllTaxRateResponse, error) {
+	page, err := newListPage(req.ListRequest, taxRateSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllTaxRateResponse{}
+
+	resp.TaxRates = make([]models.TaxRate, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + taxRateColumns + `
+			FROM "tax_rate"
+		`)
+	if req.Name != "" {
+		q.Where(`"name" ILIKE '%' || ? || '%'`, req.Name)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "tax rate")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		rate, err := scanTaxRate(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, rate.ID) {
+			break
+		}
+		resp.TaxRates = append(resp.TaxRates, *rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "tax rate")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// UpdateTaxRate overwrites the tax rate. Lines already scanned keep the rate
+// they were taxed at.
+func (r *taxRateRepo) UpdateTaxRate(ctx context.Context, req *models.UpdateTaxRate) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "tax_rate", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	query := `UPDATE "tax_rate"
+	            SET "name" = $1,
+				    "rate" = $2,
+				    "updated_at" = NOW()
+				WHERE "id" = $3`
+
+	result, err := ch.tx.Exec(ctx, query, req.Name, req.Rate, req.ID)
+	if err != nil {
+		return "", wrapError(err, "tax rate")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("tax rate")
+	}
+
+	return req.ID, nil
+}
+
+func (r *taxRateRepo) DeleteTaxRate(ctx context.Context, req *models.TaxRateIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "tax_rate", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "tax_rate" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "tax rate")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("tax rate")
+	}
+
+	return req.Id, nil
+}
+
+// scanTaxRate scans taxRateColumns, after the leading columns of a list query
+// when lead is given.
+func scanTaxRate(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.TaxRate, error) {
+	var (
+		rate      models.TaxRate
+		createdAt time.Time
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(append(lead,
+		&rate.ID,
+		&rate.Name,
+		&rate.Rate,
+		&createdAt,
+		&updatedAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	rate.CreatedAt = createdAt.Format(time.RFC3339)
+	if updatedAt.Valid {
+		rate.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/query"
+	"WareHouseProjects/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
+)
+
+// unitSortColumns are the fields the list may be sorted by.
+var unitSortColumns = map[string]sortColumn{
+	"name":       {Name: "name", Type: "text"},
+	"short_name": {Name: "short_name", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const unitColumns = `
+	"id",
+	"name",
+	"short_name",
+	"precision",
+	"created_at",
+	"updated_at"`
+
+type unitRepo struct {
+	db dbtx
+}
+
+func NewUnitRepo(db dbtx) *unitRepo {
+	return &unitRepo{
+		db: db,
+	}
+}
+
+func (r *unitRepo) CreateUnit(ctx context.Context, req *models.CreateUnit) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "unit", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "unit"(
+			"id",
+			"name",
+			"short_name",
+			"precision",
+			"created_at")
+		VALUES ($1, $2, $3, $4, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Name,
+		req.ShortName,
+		req.Precision,
+	)
+	if err != nil {
+		return "", wrapError(err, "unit")
+	}
+
+	return id, nil
+}
+
+func (r *unitRepo) GetUnit(ctx context.Context, req *models.UnitIdRequest) (*models.Unit, error) {
+	query := `SELECT ` + unitColumns + ` FROM "unit" WHERE "id" = $1`
+
+	unit, err := scanUnit(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "unit")
+	}
+
+	return unit, nil
+}
+
+func (r *unitRepo) GetAllUnit(ctx context.Context, req *models.GetAllUnitRequest) (*models.GetAllUnitResponse, error) {
+	page, err := newListPage(req.ListRequest, unitSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllUnitResponse{}
+
+	resp.Units = make([]models.Unit, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + unitColumns + `
+			FROM "unit"
+		`)
+	if req.Name != "" {
+		q.Where(`("name" ILIKE '%' || ? || '%' OR "short_name" ILIKE '%' || ? || '%')`, req.Name, req.Name)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "unit")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		unit, err := scanUnit(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, unit.ID) {
+			break
+		}
+		resp.Units = append(resp.Units, *unit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "unit")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// UpdateUnit overwrites the unit. Counts already stored are kept even when
+// the new precision would not allow them.
+func (r *unitRepo) UpdateUnit(ctx context.Context, req *models.UpdateUnit) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "unit", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	query := `UPDATE "unit"
+	            SET "name" = $1,
+				    "short_name" = $2,
+				    "precision" = $3,
+				    "updated_at" = NOW()
+				WHERE "id" = $4`
+
+	result, err := ch.tx.Exec(ctx, query, req.Name, req.ShortName, req.Precision, req.ID)
+	if err != nil {
+		return "", wrapError(err, "unit")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("unit")
+	}
+
+	return req.ID, nil
+}
+
+func (r *unitRepo) DeleteUnit(ctx context.Context, req *models.UnitIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "unit", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "unit" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "unit")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("unit")
+	}
+
+	return req.Id, nil
+}
+
+// scanUnit scans unitColumns, after the leading columns of a list query when
+// lead is given.
+func scanUnit(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.Unit, error) {
+	var (
+		unit      models.Unit
+		createdAt time.Time
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(append(lead,
+		&unit.ID,
+		&unit.Name,
+		&unit.ShortName,
+		&unit.Precision,
+		&createdAt,
+		&updatedAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.CreatedAt = createdAt.Format(time.RFC3339)
+	if updatedAt.Valid {
+		unit.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
+	}
+
+	return &unit, nil
+}
+
+// checkCount fails with a validation error when count has more decimals than
+// the unit of the product with barcode allows. Products without a unit, and
+// barcodes of no product, take any count.
+func checkCount(ctx context.Context, db dbtx, barcode string, count decimal.Decimal) error {
+	var (
+		shortName string
+		precision int32
+	)
+	query := `
+		SELECT u."short_name", u."precision"
+		FROM "product" p
+		JOIN "unit" u ON u."id" = p."unit_id"
+		WHERE p."barcode" = $1`
+
+	err := db.QueryRow(ctx, query, barcode).Scan(&shortName, &precision)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return wrapError(err, "unit")
+	}
+
+	if count.Equal(count.Truncate(precision)) {
+		return nil
+	}
+	if precision == 0 {
+		return storage.NewFieldError(storage.ErrValidation, "count", fmt.Sprintf("count of %s in %s must be a whole number", barcode, shortName), nil)
+	}
+	return storage.NewFieldError(storage.ErrValidation, "count", fmt.Sprintf("count of %s in %s allows at most %d decimals", barcode, shortName, precision), nil)
+}
+
+// unitOf converts the scanned short name and precision of a unit, which are
+// NULL for a product without one.
+func unitOf(shortName sql.NullString, precision sql.NullInt32) (string, *int) {
+	if !precision.Valid {
+		return shortName.String, nil
+	}
+	p := int(precision.Int32)
+	return shortName.String, &p
+}
@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"WareHouseProjects/models"
+	"WareHouseProjects/pkg/helper"
+	"WareHouseProjects/pkg/query"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userSortColumns are the fields the list may be sorted by.
+var userSortColumns = map[string]sortColumn{
+	"username":   {Name: "username", Type: "text"},
+	"created_at": {Name: "created_at", Type: "timestamp"},
+}
+
+const userColumns = `
+	"id",
+	"username",
+	"role",
+	"branch_id",
+	"created_at",
+	"updated_at"`
+
+type userRepo struct {
+	db dbtx
+}
+
+func NewUserRepo(db dbtx) *userRepo {
+	return &userRepo{
+		db: db,
+	}
+}
+
+func (r *userRepo) CreateUser(ctx context.Context, req *models.CreateUser) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "user", models.AuditCreate, "")
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, resp, err) }()
+
+	var (
+		id = uuid.NewString()
+	)
+
+	query := `
+		INSERT INTO "users"(
+			"id",
+			"username",
+			"password_hash",
+			"role",
+			"branch_id",
+			"created_at")
+		VALUES ($1, $2, $3, $4, $5, NOW())`
+
+	_, err = ch.tx.Exec(ctx, query,
+		id,
+		req.Username,
+		req.PasswordHash,
+		string(req.Role),
+		helper.NewNullString(req.Branch_id),
+	)
+	if err != nil {
+		return "", wrapError(err, "user")
+	}
+
+	return id, nil
+}
+
+func (r *userRepo) GetUser(ctx context.Context, req *models.UserIdRequest) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM "users" WHERE "id" = $1`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, req.Id))
+	if err != nil {
+		return nil, wrapError(err, "user")
+	}
+
+	return user, nil
+}
+
+func (r *userRepo) GetAllUser(ctx context.Context, req *models.GetAllUserRequest) (*models.GetAllUserResponse, error) {
+	page, err := newListPage(req.ListRequest, userSortColumns)
+	if err != nil {
+		return nil, err
+	}
+	var resp = &models.GetAllUserResponse{}
+
+	resp.Users = make([]models.User, 0)
+
+	q := query.Select(`
+			SELECT
+				` + page.columns() + userColumns + `
+			FROM "users"
+		`)
+	if req.Username != "" {
+		q.Where(`"username" ILIKE '%' || ? || '%'`, req.Username)
+	}
+	if req.Role != "" {
+		q.Where(`"role" = ?`, string(req.Role))
+	}
+	if req.Branch_id != "" {
+		q.Where(`"branch_id" = ?`, req.Branch_id)
+	}
+	page.apply(q)
+	rquery, args := q.Build()
+
+	rows, err := r.db.Query(ctx, rquery, args...)
+	if err != nil {
+		return nil, wrapError(err, "user")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sortKey string
+		user, err := scanUser(rows, &resp.Count, &sortKey)
+		if err != nil {
+			return nil, err
+		}
+		if !page.keep(sortKey, user.ID) {
+			break
+		}
+		resp.Users = append(resp.Users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "user")
+	}
+	resp.NextCursor = page.nextCursor()
+
+	return resp, nil
+}
+
+// UpdateUser overwrites the user. An empty req.PasswordHash keeps the current
+// password.
+func (r *userRepo) UpdateUser(ctx context.Context, req *models.UpdateUser) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "user", models.AuditUpdate, req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.ID, err) }()
+
+	query := `UPDATE "users"
+	            SET "username" = $1,
+				    "password_hash" = COALESCE(NULLIF($2, ''), "password_hash"),
+				    "role" = $3,
+				    "branch_id" = $4,
+				    "updated_at" = NOW()
+				WHERE "id" = $5`
+
+	result, err := ch.tx.Exec(ctx, query, req.Username, req.PasswordHash, string(req.Role), helper.NewNullString(req.Branch_id), req.ID)
+	if err != nil {
+		return "", wrapError(err, "user")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("user")
+	}
+
+	return req.ID, nil
+}
+
+// DeleteUser deletes the user together with its API keys and refresh tokens.
+func (r *userRepo) DeleteUser(ctx context.Context, req *models.UserIdRequest) (resp string, err error) {
+	ch, err := beginChange(ctx, r.db, "user", models.AuditDelete, req.Id)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = ch.end(ctx, req.Id, err) }()
+
+	query := `DELETE FROM "users" WHERE "id" = $1`
+
+	result, err := ch.tx.Exec(ctx, query, req.Id)
+	if err != nil {
+		return "", wrapError(err, "user")
+	}
+
+	if result.RowsAffected() == 0 {
+		return "", notFound("user")
+	}
+
+	return req.Id, nil
+}
+
+func (r *userRepo) GetUserCredentials(ctx context.Context, username string) (*models.UserCredentials, error) {
+	query := `SELECT "id", "password_hash" FROM "users" WHERE "username" = $1`
+
+	var creds models.UserCredentials
+	err := r.db.QueryRow(ctx, query, username).Scan(&creds.ID, &creds.PasswordHash)
+	if err != nil {
+		return nil, wrapError(err, "user")
+	}
+
+	return &creds, nil
+}
+
+// CreateRefreshToken records a refresh token as issued.
+func (r *userRepo) CreateRefreshToken(ctx context.Context, req *models.CreateRefreshToken) error {
+	query := `
+		INSERT INTO "refresh_token"("id", "user_id", "expires_at", "created_at")
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second', NOW())`
+
+	_, err := r.db.Exec(ctx, query, req.ID, req.UserID, req.TTL)
+	if err != nil {
+		return wrapError(err, "refresh token")
+	}
+
+	return nil
+}
+
+// UseRefreshToken marks the refresh token with id as used and returns its
+// user. A token that is unknown, expired or used already is not found.
+func (r *userRepo) UseRefreshToken(ctx context.Context, id string) (string, error) {
+	query := `
+		UPDATE "refresh_token"
+		SET "used_at" = NOW()
+		WHERE "id" = $1 AND "used_at" IS NULL AND "expires_at" > NOW()
+		RETURNING "user_id"`
+
+	var userID string
+	if err := r.db.QueryRow(ctx, query, id).Scan(&userID); err != nil {
+		return "", wrapError(err, "refresh token")
+	}
+
+	return userID, nil
+}
+
+// scanUser scans userColumns, after the leading columns of a list query when
+// lead is given.
+func scanUser(row interface{ Scan(...interface{}) error }, lead ...interface{}) (*models.User, error) {
+	var (
+		user      models.User
+		role      string
+		branchID  sql.NullString
+		createdAt time.Time
+		updatedAt sql.NullTime
+	)
+	err := row.Scan(append(lead,
+		&user.ID,
+		&user.Username,
+		&role,
+		&branchID,
+		&createdAt,
+		&updatedAt,
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = models.Role(role)
+	user.Branch_id = branchID.String
+	user.CreatedAt = createdAt.Format(time.RFC3339)
+	if updatedAt.Valid {
+		user.UpdatedAt = updatedAt.Time.Format(time.RFC3339)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import "context"
+
+type branchScopeKey struct{}
+
+// WithBranchScope limits the coming tables, their lines and the stock read or
+// written through ctx to those of branchID. Others are not found, and moving
+// a record to another branch is forbidden.
+func WithBranchScope(ctx context.Context, branchID string) context.Context {
+	return context.WithValue(ctx, branchScopeKey{}, branchID)
+}
+
+// BranchScope returns the branch ctx is limited to, or "" when it is not.
+func BranchScope(ctx context.Context) string {
+	branchID, _ := ctx.Value(branchScopeKey{}).(string)
+	return branchID
+}
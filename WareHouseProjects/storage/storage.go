@@ -1,74 +1,163 @@
 package storage
 
-import models "WareHouseProjects/models"
+import (
+	models "WareHouseProjects/models"
+	"context"
+)
 
 type StorageI interface {
 	Branch() BranchesI
+	BranchPrice() BranchPricesI
 	Category() CategoriesI
 	Product() ProdouctsI
+	ProductPrice() ProductPricesI
 	Coming_Table() Coming_TableI
 	Coming_TableProduct() Coming_TableProductI
 	Remaining() RemainingI
+	Unit() UnitsI
+	ExchangeRate() ExchangeRatesI
+	TaxRate() TaxRatesI
+	DocumentNumber() DocumentNumbersI
+	User() UsersI
+	ApiKey() ApiKeysI
+	Audit() AuditI
 
+	WithTx(ctx context.Context, fn func(StorageI) error) error
 	Close()
 }
 
 type BranchesI interface {
-	CreateBranch(*models.CreateBranch) (string, error)
-	GetBranch(*models.BranchIdRequest) (*models.Branch, error)
-	GetAllBranch(*models.GetAllBranchRequest) (*models.GetAllBranchResponse, error)
-	UpdateBranch(*models.UpdateBranch) (string, error)
-	DeleteBranch(*models.BranchIdRequest) (string, error)
+	CreateBranch(context.Context, *models.CreateBranch) (string, error)
+	GetBranch(context.Context, *models.BranchIdRequest) (*models.Branch, error)
+	GetAllBranch(context.Context, *models.GetAllBranchRequest) (*models.GetAllBranchResponse, error)
+	UpdateBranch(context.Context, *models.UpdateBranch) (string, error)
+	DeleteBranch(context.Context, *models.BranchIdRequest) (string, error)
+}
+
+type BranchPricesI interface {
+	CreateBranchPrice(context.Context, *models.CreateBranchPrice) (string, error)
+	GetBranchPrice(context.Context, *models.BranchPriceIdRequest) (*models.BranchPrice, error)
+	GetAllBranchPrice(context.Context, *models.GetAllBranchPriceRequest) (*models.GetAllBranchPriceResponse, error)
+	UpdateBranchPrice(context.Context, *models.UpdateBranchPrice) (string, error)
+	DeleteBranchPrice(context.Context, *models.BranchPriceIdRequest) (string, error)
 }
 
 type CategoriesI interface {
-	CreateCategory(*models.CreateCategory) (string, error)
-	GetCategory(*models.CategoryIdRequest) (*models.Category, error)
-	GetAllCategory(*models.GetAllCategoryRequest) (*models.GetAllCategoryResponse, error)
-	UpdateCategory(*models.UpdateCategory) (string, error)
-	DeleteCategory(*models.CategoryIdRequest) (string, error)
+	CreateCategory(context.Context, *models.CreateCategory) (string, error)
+	GetCategory(context.Context, *models.CategoryIdRequest) (*models.Category, error)
+	GetAllCategory(context.Context, *models.GetAllCategoryRequest) (*models.GetAllCategoryResponse, error)
+	UpdateCategory(context.Context, *models.UpdateCategory) (string, error)
+	DeleteCategory(context.Context, *models.CategoryIdRequest) (string, error)
+
+	EnsureCategoryPath(context.Context, []string) (string, int, error)
 }
 
 type ProdouctsI interface {
-	CreateProduct(*models.CreateProduct) (string, error)
-	GetProduct(*models.ProductIdRequest) (*models.Product, error)
-	GetAllProduct(*models.GetAllProductRequest) (*models.GetAllProductResponse, error)
-	UpdateProduct(*models.UpdateProduct) (string, error)
-	DeleteProduct(*models.ProductIdRequest) (string, error)
+	CreateProduct(context.Context, *models.CreateProduct) (string, error)
+	GetProduct(context.Context, *models.ProductIdRequest) (*models.Product, error)
+	GetAllProduct(context.Context, *models.GetAllProductRequest) (*models.GetAllProductResponse, error)
+	UpdateProduct(context.Context, *models.UpdateProduct) (string, error)
+	DeleteProduct(context.Context, *models.ProductIdRequest) (string, error)
+
+	GetProductByBarcode(context.Context, *models.CheckBarcodeComingTable) (*models.RespBarcodeProduct, error)
+	SearchProduct(context.Context, *models.SearchProductRequest) ([]models.ProductSearchResult, error)
+	UpsertProduct(context.Context, *models.CreateProduct) (string, bool, error)
+}
+
+type ProductPricesI interface {
+	CreateProductPrice(context.Context, *models.CreateProductPrice) (string, error)
+	GetProductPrice(context.Context, *models.ProductPriceIdRequest) (*models.ProductPrice, error)
+	GetAllProductPrice(context.Context, *models.GetAllProductPriceRequest) (*models.GetAllProductPriceResponse, error)
+	DeleteProductPrice(context.Context, *models.ProductPriceIdRequest) (string, error)
 
-	GetProductByBarcode(*models.CheckBarcodeComingTable) (*models.RespBarcodeProduct, error)
+	ApplyDuePrices(context.Context) (int, error)
 }
 
 type Coming_TableI interface {
-	CreateComingTable(*models.CreateComingTable) (string, error)
-	GetComingTable(*models.ComingTableIdRequest) (*models.ComingTable, error)
-	GetAllComingTable(*models.GetAllComingTableRequest) (*models.GetAllComingTableResponse, error)
-	UpdateComingTable(*models.UpdateComingTable) (string, error)
-	DeleteComingTable(*models.ComingTableIdRequest) (string, error)
+	CreateComingTable(context.Context, *models.CreateComingTable) (string, error)
+	GetComingTable(context.Context, *models.ComingTableIdRequest) (*models.ComingTable, error)
+	GetAllComingTable(context.Context, *models.GetAllComingTableRequest) (*models.GetAllComingTableResponse, error)
+	UpdateComingTable(context.Context, *models.UpdateComingTable) (string, error)
+	DeleteComingTable(context.Context, *models.ComingTableIdRequest) (string, error)
 
-	GetStatus(*models.ComingTableIdRequest) (string, error)
-	UpdateStatus(req *models.ComingTableIdRequest) (string, error)
+	GetStatus(context.Context, *models.ComingTableIdRequest) (string, error)
+	UpdateStatus(ctx context.Context, req *models.ComingTableIdRequest) (string, error)
 }
 
 type Coming_TableProductI interface {
-	CreateComingTableProduct(*models.CreateComingTableProduct) (string, error)
-	GetComingTableProduct(*models.ComingTableProductIdRequest) (*models.ComingTableProduct, error)
-	GetAllComingTableProduct(*models.GetAllComingTableProductRequest) (*models.GetAllComingTableProductResponse, error)
-	UpdateComingTableProduct(*models.UpdateComingTableProduct) (string, error)
-	DeleteComingTableProduct(*models.ComingTableProductIdRequest) (string, error)
+	CreateComingTableProduct(context.Context, *models.CreateComingTableProduct) (string, error)
+	GetComingTableProduct(context.Context, *models.ComingTableProductIdRequest) (*models.ComingTableProduct, error)
+	GetAllComingTableProduct(context.Context, *models.GetAllComingTableProductRequest) (*models.GetAllComingTableProductResponse, error)
+	UpdateComingTableProduct(context.Context, *models.UpdateComingTableProduct) (string, error)
+	DeleteComingTableProduct(context.Context, *models.ComingTableProductIdRequest) (string, error)
 
-	CheckAviableProduct(*models.CheckBarcodeComingTable) (string, error)
-	UpdateIdAviable(*models.UpdateComingTableProduct) (string, error)
-	GetComingTableById(*models.ComingTableProductIdRequest) (*models.ComingTableProduct, error)
+	CheckAviableProduct(context.Context, *models.CheckBarcodeComingTable) (string, error)
+	UpdateIdAviable(context.Context, *models.UpdateComingTableProduct) (string, error)
+	GetComingTableById(context.Context, *models.ComingTableProductIdRequest) ([]models.ComingTableProduct, error)
+	GetComingTableTaxes(context.Context, *models.ComingTableIdRequest) ([]models.ComingTableTax, error)
 }
 
 type RemainingI interface {
-	CreateRemain(*models.CreateRemain) (string, error)
-	GetRemain(*models.RemainIdRequest) (*models.Remain, error)
-	GetAllRemain(*models.GetAllRemainRequest) (resp *models.GetAllRemainResponse, err error)
-	UpdateRemain(*models.UpdateRemain) (string, error)
-	DeleteRemain(*models.RemainIdRequest) (string, error)
-
-	UpdateIdAviable(req *models.UpdateRemain) (string, error)
-	CheckRemain(req *models.CheckRemain) (string, error)
+	CreateRemain(context.Context, *models.CreateRemain) (string, error)
+	GetRemain(context.Context, *models.RemainIdRequest) (*models.Remain, error)
+	GetAllRemain(context.Context, *models.GetAllRemainRequest) (resp *models.GetAllRemainResponse, err error)
+	UpdateRemain(context.Context, *models.UpdateRemain) (string, error)
+	DeleteRemain(context.Context, *models.RemainIdRequest) (string, error)
+
+	AddRemain(ctx context.Context, req *models.CreateRemain) (string, error)
+}
+
+type UnitsI interface {
+	CreateUnit(context.Context, *models.CreateUnit) (string, error)
+	GetUnit(context.Context, *models.UnitIdRequest) (*models.Unit, error)
+	GetAllUnit(context.Context, *models.GetAllUnitRequest) (*models.GetAllUnitResponse, error)
+	UpdateUnit(context.Context, *models.UpdateUnit) (string, error)
+	DeleteUnit(context.Context, *models.UnitIdRequest) (string, error)
+}
+
+type ExchangeRatesI interface {
+	CreateExchangeRate(context.Context, *models.CreateExchangeRate) (string, error)
+	GetExchangeRate(context.Context, *models.ExchangeRateIdRequest) (*models.ExchangeRate, error)
+	GetAllExchangeRate(context.Context, *models.GetAllExchangeRateRequest) (*models.GetAllExchangeRateResponse, error)
+	UpdateExchangeRate(context.Context, *models.UpdateExchangeRate) (string, error)
+	DeleteExchangeRate(context.Context, *models.ExchangeRateIdRequest) (string, error)
+
+	GetExchangeRateOn(context.Context, *models.ExchangeRateOn) (*models.ExchangeRate, error)
+}
+
+type TaxRatesI interface {
+	CreateTaxRate(context.Context, *models.CreateTaxRate) (string, error)
+	GetTaxRate(context.Context, *models.TaxRateIdRequest) (*models.TaxRate, error)
+	GetAllTaxRate(context.Context, *models.GetAllTaxRateRequest) (*models.GetAllTaxRateResponse, error)
+	UpdateTaxRate(context.Context, *models.UpdateTaxRate) (string, error)
+	DeleteTaxRate(context.Context, *models.TaxRateIdRequest) (string, error)
+}
+
+type DocumentNumbersI interface {
+	NextDocumentNumber(context.Context, *models.NextDocumentNumber) (*models.DocumentNumber, error)
+}
+
+type UsersI interface {
+	CreateUser(context.Context, *models.CreateUser) (string, error)
+	GetUser(context.Context, *models.UserIdRequest) (*models.User, error)
+	GetAllUser(context.Context, *models.GetAllUserRequest) (*models.GetAllUserResponse, error)
+	UpdateUser(context.Context, *models.UpdateUser) (string, error)
+	DeleteUser(context.Context, *models.UserIdRequest) (string, error)
+
+	GetUserCredentials(ctx context.Context, username string) (*models.UserCredentials, error)
+	CreateRefreshToken(context.Context, *models.CreateRefreshToken) error
+	UseRefreshToken(ctx context.Context, id string) (string, error)
+}
+
+type ApiKeysI interface {
+	CreateApiKey(context.Context, *models.CreateApiKey) (string, error)
+	GetApiKey(context.Context, *models.ApiKeyIdRequest) (*models.ApiKey, error)
+	GetAllApiKey(context.Context, *models.GetAllApiKeyRequest) (*models.GetAllApiKeyResponse, error)
+	DeleteApiKey(context.Context, *models.ApiKeyIdRequest) (string, error)
+
+	GetApiKeyUser(ctx context.Context, keyHash string) (string, error)
+}
+
+type AuditI interface {
+	GetAllAudit(context.Context, *models.GetAllAuditRequest) (*models.GetAllAuditResponse, error)
 }